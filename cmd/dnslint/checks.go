@@ -0,0 +1,249 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package main
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/Cray-HPE/cray-powerdns-manager/internal/sls"
+	sls_common "github.com/Cray-HPE/hms-sls/pkg/sls-common"
+	"github.com/mitchellh/mapstructure"
+)
+
+// Severity of a problem. Errors are data the manager can't turn into DNS at all or turns into the wrong DNS, warnings
+// are data the manager quietly skips.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Problem is a single thing wrong with the SLS data.
+type Problem struct {
+	Severity    Severity `json:"severity"`
+	Check       string   `json:"check"`
+	Network     string   `json:"network"`
+	Subnet      string   `json:"subnet,omitempty"`
+	Reservation string   `json:"reservation,omitempty"`
+	Message     string   `json:"message"`
+}
+
+func (problem Problem) String() string {
+	location := problem.Network
+	if problem.Subnet != "" {
+		location = fmt.Sprintf("%s/%s", location, problem.Subnet)
+	}
+	if problem.Reservation != "" {
+		location = fmt.Sprintf("%s/%s", location, problem.Reservation)
+	}
+
+	return fmt.Sprintf("%-7s %-18s %s: %s", strings.ToUpper(string(problem.Severity)), problem.Check, location,
+		problem.Message)
+}
+
+// Problems is every problem found in the SLS data, in the order they were found.
+type Problems []Problem
+
+func (problems Problems) count() (errors int, warnings int) {
+	for _, problem := range problems {
+		if problem.Severity == SeverityError {
+			errors++
+		} else {
+			warnings++
+		}
+	}
+
+	return
+}
+
+// Names of the checks, these are what show up in the output.
+const (
+	CheckCIDR          = "unparseable-cidr"
+	CheckIPAddress     = "invalid-ip"
+	CheckOutsideSubnet = "ip-outside-subnet"
+	CheckAlias         = "dotted-alias"
+	CheckComment       = "comment-not-xname"
+	CheckDuplicate     = "duplicate-name"
+	CheckHSNNID        = "hsn-nid"
+)
+
+// nameLocation is where a DNS name was first generated from.
+type nameLocation struct {
+	subnet      string
+	reservation string
+	ipAddress   string
+}
+
+// lint runs all the checks against the (already combined) networks.
+func lint(networks []sls_common.Network, hardware []sls_common.GenericHardware) (problems Problems) {
+	problems = Problems{}
+
+	hardwareMap := sls.GetHardwareMap(hardware)
+
+	for _, network := range networks {
+		networkDomain := strings.ToLower(network.Name)
+
+		add := func(severity Severity, check string, subnet string, reservation string, format string,
+			a ...interface{}) {
+			problems = append(problems, Problem{
+				Severity:    severity,
+				Check:       check,
+				Network:     network.Name,
+				Subnet:      subnet,
+				Reservation: reservation,
+				Message:     fmt.Sprintf(format, a...),
+			})
+		}
+
+		// The IP ranges are what the manager builds the reverse zones from.
+		for _, ipRange := range network.IPRanges {
+			if _, _, err := net.ParseCIDR(ipRange); err != nil {
+				add(SeverityError, CheckCIDR, "", "", "IP range %q is not a valid CIDR", ipRange)
+			}
+		}
+
+		var networkProperties sls.NetworkExtraProperties
+		err := mapstructure.Decode(network.ExtraPropertiesRaw, &networkProperties)
+		if err != nil {
+			add(SeverityError, CheckCIDR, "", "", "failed to decode network extra properties: %s", err)
+			continue
+		}
+
+		if networkProperties.CIDR != "" {
+			if _, _, err := net.ParseCIDR(networkProperties.CIDR); err != nil {
+				add(SeverityError, CheckCIDR, "", "", "network CIDR %q is not a valid CIDR", networkProperties.CIDR)
+			}
+		}
+
+		names := make(map[string]nameLocation)
+
+		for _, subnet := range networkProperties.Subnets {
+			_, subnetCIDR, err := net.ParseCIDR(subnet.CIDR)
+			if err != nil {
+				add(SeverityError, CheckCIDR, subnet.Name, "", "subnet CIDR %q is not a valid CIDR", subnet.CIDR)
+			}
+
+			for _, reservation := range subnet.IPReservations {
+				ip := net.ParseIP(reservation.IPAddress)
+				if ip == nil {
+					add(SeverityError, CheckIPAddress, subnet.Name, reservation.Name, "%q is not a valid IP address",
+						reservation.IPAddress)
+				} else if subnetCIDR != nil && !subnetCIDR.Contains(ip) {
+					add(SeverityError, CheckOutsideSubnet, subnet.Name, reservation.Name, "%s is not in subnet %s",
+						reservation.IPAddress, subnetCIDR)
+				}
+
+				// Work out every name the manager would create for this reservation, using the manager's own rules.
+				reservationNames := []string{reservation.Name}
+
+				node, isXname := sls.GetReservationNode(reservation, hardwareMap)
+				if isXname {
+					reservationNames = append(reservationNames, node.Xname)
+				} else if reservation.Comment != "" {
+					add(SeverityWarning, CheckComment, subnet.Name, reservation.Name,
+						"comment %q is not an xname in the SLS hardware, no xname record will be created",
+						reservation.Comment)
+				}
+
+				aliases, dottedAliases := sls.GetReservationAliases(reservation, node.Xname)
+				for _, alias := range dottedAliases {
+					add(SeverityWarning, CheckAlias, subnet.Name, reservation.Name,
+						"alias %q contains a dot and will be skipped", alias)
+				}
+				reservationNames = append(reservationNames, aliases...)
+
+				seen := make(map[string]bool)
+				for _, name := range reservationNames {
+					name = strings.ToLower(name)
+					if seen[name] {
+						continue
+					}
+					seen[name] = true
+
+					location := nameLocation{
+						subnet:      subnet.Name,
+						reservation: reservation.Name,
+						ipAddress:   reservation.IPAddress,
+					}
+					first, duplicate := names[name]
+					if !duplicate {
+						names[name] = location
+						continue
+					}
+
+					// The same name pointing at the same address is harmless, the last one just wins.
+					severity := SeverityError
+					if first.ipAddress == location.ipAddress {
+						severity = SeverityWarning
+					}
+					add(severity, CheckDuplicate, subnet.Name, reservation.Name,
+						"%s.%s (%s) is already defined by %s/%s (%s)", name, networkDomain, location.ipAddress,
+						first.subnet, first.reservation, first.ipAddress)
+				}
+
+				if networkDomain == "hsn" {
+					if severity, err := checkHSNNID(reservation.Name, hardwareMap); err != nil {
+						add(severity, CheckHSNNID, subnet.Name, reservation.Name, "%s", err)
+					}
+				}
+			}
+		}
+	}
+
+	return
+}
+
+// checkHSNNID makes sure the manager can find the NID for an HSN reservation. Only SLS is available to the linter so
+// a node without a NID in SLS is only a warning, application nodes get theirs from HSM.
+func checkHSNNID(reservation string, hardwareMap map[string]sls_common.GenericHardware) (severity Severity,
+	err error) {
+	severity = SeverityError
+
+	xname, _, err := sls.ParseHSNReservationName(reservation)
+	if err != nil {
+		return
+	}
+
+	node, found := hardwareMap[xname]
+	if !found {
+		err = fmt.Errorf("node %s is not in the SLS hardware", xname)
+		return
+	}
+
+	nid, err := sls.GetNodeNID(node)
+	if err != nil {
+		return
+	}
+	if nid == 0 {
+		severity = SeverityWarning
+		err = fmt.Errorf("node %s has no NID in SLS, it will only resolve if HSM has one", xname)
+	}
+
+	return
+}
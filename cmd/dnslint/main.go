@@ -0,0 +1,145 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Cray-HPE/cray-powerdns-manager/internal/sls"
+	sls_common "github.com/Cray-HPE/hms-sls/pkg/sls-common"
+	"github.com/hashicorp/go-retryablehttp"
+	"github.com/namsral/flag"
+)
+
+// Exit codes, anything other than exitOK means the data would not make it into DNS the way it's written in SLS.
+const (
+	exitOK       = 0
+	exitProblems = 1
+	exitFailure  = 2
+)
+
+var (
+	slsURL  = flag.String("sls_url", "", "System Layout Service URL to lint, mutually exclusive with sls_file")
+	slsFile = flag.String("sls_file", "",
+		"SLS dump file (the output of the SLS dumpstate endpoint) to lint, mutually exclusive with sls_url")
+	ignoreSLSNetworks = flag.String("sls_ignore", "BICAN",
+		"Comma separated list of SLS networks to ignore, should match the manager")
+	outputFormat = flag.String("output", "text", "Output format, either text or json")
+	strict       = flag.Bool("strict", false, "Exit non-zero for warnings as well as errors")
+)
+
+func fail(format string, a ...interface{}) {
+	fmt.Fprintf(os.Stderr, format+"\n", a...)
+	os.Exit(exitFailure)
+}
+
+func loadSLS() (networks []sls_common.Network, hardware []sls_common.GenericHardware, err error) {
+	if *slsFile != "" {
+		return sls.ReadDumpFile(*slsFile)
+	}
+
+	httpClient := retryablehttp.NewClient()
+	httpClient.HTTPClient.Transport = &http.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	}
+	httpClient.RetryMax = 3
+	httpClient.RetryWaitMax = time.Second * 2
+	httpClient.Logger = nil
+
+	ctx := context.Background()
+	token := os.Getenv("TOKEN")
+
+	networks, err = sls.GetNetworks(ctx, httpClient, *slsURL, token)
+	if err != nil {
+		err = fmt.Errorf("failed to get SLS networks: %w", err)
+		return
+	}
+
+	hardware, err = sls.GetHardware(ctx, httpClient, *slsURL, token)
+	if err != nil {
+		err = fmt.Errorf("failed to get SLS hardware: %w", err)
+	}
+
+	return
+}
+
+func main() {
+	// Parse the arguments.
+	flag.Parse()
+
+	if (*slsURL == "") == (*slsFile == "") {
+		fail("Exactly one of sls_url or sls_file must be given!")
+	}
+	if *outputFormat != "text" && *outputFormat != "json" {
+		fail("Output format must be text or json, not %s!", *outputFormat)
+	}
+
+	networks, hardware, err := loadSLS()
+	if err != nil {
+		fail("Failed to load SLS data: %s", err)
+	}
+
+	// Lint the networks the same way the manager sees them.
+	var networksToIgnore []string
+	if *ignoreSLSNetworks != "" {
+		networksToIgnore = strings.Split(*ignoreSLSNetworks, ",")
+	}
+	networks, err = sls.CombineNetworks(networks, networksToIgnore)
+	if err != nil {
+		fail("Failed to combine SLS networks: %s", err)
+	}
+
+	problems := lint(networks, hardware)
+
+	if *outputFormat == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(problems); err != nil {
+			fail("Failed to encode problems: %s", err)
+		}
+	} else {
+		for _, problem := range problems {
+			fmt.Println(problem)
+		}
+	}
+
+	errors, warnings := problems.count()
+	if *outputFormat == "text" {
+		fmt.Printf("%d error(s), %d warning(s)\n", errors, warnings)
+	}
+
+	if errors > 0 || (*strict && warnings > 0) {
+		os.Exit(exitProblems)
+	}
+	os.Exit(exitOK)
+}
@@ -34,6 +34,9 @@ import (
 func setupAPI() {
 	router = gin.Default()
 
+	// Prometheus metrics live outside of the versioned API where scrapers expect them.
+	router.GET("/metrics", gin.WrapH(managerMetrics.Handler()))
+
 	// Version everything.
 	apiV1 := router.Group("/v1")
 
@@ -41,6 +41,7 @@ import (
 
 	"github.com/Cray-HPE/cray-powerdns-manager/internal/common"
 	"github.com/Cray-HPE/cray-powerdns-manager/internal/httpLogger"
+	"github.com/Cray-HPE/cray-powerdns-manager/internal/metrics"
 	"github.com/gin-gonic/gin"
 	"github.com/hashicorp/go-retryablehttp"
 	"github.com/joeig/go-powerdns/v2"
@@ -53,6 +54,7 @@ var (
 	pdnsURL             = flag.String("pdns_url", "http://localhost:9090", "PowerDNS URL")
 	pdnsAPIKey          = flag.String("pdns_api_key", "cray", "PowerDNS API Key")
 	trueUpSleepInterval = flag.Int("true_up_sleep_interval", 30, "Time to sleep between true up runs")
+	ttlPTR              = flag.Uint("ttl_ptr", uint(common.DefaultTTL), "TTL of generated PTR and TXT RRsets")
 
 	pdns *powerdns.Client
 
@@ -72,6 +74,10 @@ var (
 	atomicLevel zap.AtomicLevel
 	logger      *zap.Logger
 
+	managerMetrics *metrics.Metrics
+
+	recordTTL uint32
+
 	Running = true
 )
 
@@ -123,7 +129,7 @@ func ProcessExternalDNSRecord(rrSet powerdns.RRset, zone *powerdns.Zone, allZone
 				rrSetReverse = powerdns.RRset{
 					Name:       powerdns.String(common.MakeDomainCanonical(common.GetReverseName(strings.Split(*record.Content, ".")))),
 					Type:       powerdns.RRTypePtr(powerdns.RRTypePTR),
-					TTL:        powerdns.Uint32(3600),
+					TTL:        powerdns.Uint32(recordTTL),
 					ChangeType: powerdns.ChangeTypePtr(powerdns.ChangeTypeReplace),
 					Records: []powerdns.Record{
 						{
@@ -138,7 +144,7 @@ func ProcessExternalDNSRecord(rrSet powerdns.RRset, zone *powerdns.Zone, allZone
 				rrSetTXT = powerdns.RRset{
 					Name:       powerdns.String(common.MakeDomainCanonical(common.GetReverseName(strings.Split(*record.Content, ".")))),
 					Type:       powerdns.RRTypePtr(powerdns.RRTypeTXT),
-					TTL:        powerdns.Uint32(3600),
+					TTL:        powerdns.Uint32(recordTTL),
 					ChangeType: powerdns.ChangeTypePtr(powerdns.ChangeTypeReplace),
 					Records: []powerdns.Record{
 						{
@@ -239,6 +245,9 @@ func doLoop() {
 		trueUpInProgress = true
 		trueUpMtx.Unlock()
 
+		start := time.Now()
+		success := true
+
 		// do stuff here
 		logger.Info("Processing records...")
 
@@ -357,12 +366,23 @@ func doLoop() {
 				err := pdns.Records.Patch(zone, rrSets)
 				if err != nil {
 					zoneLogger.Error("Failed to patch RRSets!", zap.Error(err), zap.Any("zone", zone))
+					managerMetrics.PatchFailures.WithLabelValues(zone).Inc()
+					success = false
 				} else {
 					zoneLogger.Info("Patched RRSets")
+					for _, rrSet := range rrSets.Sets {
+						change := "replace"
+						if *rrSet.ChangeType == powerdns.ChangeTypeDelete {
+							change = "delete"
+						}
+						managerMetrics.RRsetsPatched.WithLabelValues(zone, change).Inc()
+					}
 				}
 			}
 		}
 
+		managerMetrics.ObserveTrueUp(start, success)
+
 		trueUpMtx.Lock()
 		trueUpInProgress = false
 		trueUpMtx.Unlock()
@@ -418,6 +438,14 @@ func main() {
 	// Setup logging
 	setupLogging()
 
+	managerMetrics = metrics.NewMetrics("cray_externaldns_manager")
+
+	var err error
+	recordTTL, err = common.ValidateTTL(*ttlPTR)
+	if err != nil {
+		logger.Fatal("Invalid TTL!", zap.Error(err))
+	}
+
 	// var cancel context.CancelFunc
 	// ctx, cancel = context.WithCancel(context.Background())
 
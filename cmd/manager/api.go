@@ -1,14 +1,64 @@
 package main
 
 import (
+	"errors"
+	"fmt"
+	"github.com/Cray-HPE/cray-powerdns-manager/internal/common"
+	"github.com/Cray-HPE/hms-base"
+	"github.com/Cray-HPE/hms-smd/pkg/sm"
 	"github.com/gin-gonic/gin"
+	"github.com/joeig/go-powerdns/v2"
 	"go.uber.org/zap"
 	"net/http"
+	"strings"
 )
 
+// Problem7807 is an RFC 7807 compliant error payload.
+type Problem7807 struct {
+	Type   string `json:"type"`
+	Title  string `json:"title,omitempty"`
+	Detail string `json:"detail,omitempty"`
+	Status int    `json:"status,omitempty"`
+}
+
+func sendProblem(c *gin.Context, status int, detail string) {
+	c.JSON(status, Problem7807{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Detail: detail,
+		Status: status,
+	})
+}
+
+// getRecordParams returns the record name and type from the path in the same form they are stored.
+func getRecordParams(c *gin.Context) (name string, rrType powerdns.RRType) {
+	name = common.MakeDomainCanonical(strings.ToLower(c.Param("name")))
+	rrType = powerdns.RRType(strings.ToUpper(c.Param("type")))
+
+	return
+}
+
+// sendRecordProblem sends the problem matching an error from changing the operator defined records.
+func sendRecordProblem(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, errManualRecordInvalid):
+		sendProblem(c, http.StatusBadRequest, err.Error())
+	case errors.Is(err, errManualRecordExists):
+		sendProblem(c, http.StatusConflict, err.Error())
+	case errors.Is(err, errManualRecordNotFound):
+		sendProblem(c, http.StatusNotFound, err.Error())
+	default:
+		logger.Error("Failed to change records!", zap.Error(err))
+		sendProblem(c, http.StatusInternalServerError, err.Error())
+	}
+}
+
 func setupAPI() {
 	router = gin.Default()
 
+	// Prometheus metrics live outside of the versioned API where scrapers expect them.
+	router.GET("/metrics", gin.WrapH(managerMetrics.Handler()))
+
 	// Version everything.
 	apiV1 := router.Group("/v1")
 
@@ -17,19 +67,212 @@ func setupAPI() {
 		c.JSON(http.StatusNoContent, nil)
 	})
 	apiV1.GET("/readiness", func(c *gin.Context) {
+		status := getReadiness()
+		if !status.Ready {
+			c.JSON(http.StatusServiceUnavailable, status)
+			return
+		}
+
 		c.JSON(http.StatusNoContent, nil)
 	})
 
 	// True up loop control.
 	apiV1.POST("/manager/jobs", func(c *gin.Context) {
 		trueUpMtx.Lock()
-		if trueUpInProgress {
+		defer trueUpMtx.Unlock()
+
+		// A job that has been queued but not yet picked up by the loop counts as in progress too.
+		if trueUpInProgress || len(trueUpRunNow) > 0 {
 			c.JSON(http.StatusServiceUnavailable, nil)
-		} else {
-			trueUpRunNow <- true
-			c.JSON(http.StatusNoContent, nil)
+			return
 		}
+
+		job := newJob(JobTriggerAPI)
+		trueUpRunNow <- job
+
+		queuedJob, _ := getJob(job.ID)
+		c.JSON(http.StatusAccepted, queuedJob)
+	})
+	apiV1.GET("/manager/jobs", func(c *gin.Context) {
+		c.JSON(http.StatusOK, getJobs())
+	})
+	apiV1.GET("/manager/jobs/:id", func(c *gin.Context) {
+		job, found := getJob(c.Param("id"))
+		if !found {
+			sendProblem(c, http.StatusNotFound, fmt.Sprintf("no job with ID %s", c.Param("id")))
+			return
+		}
+
+		c.JSON(http.StatusOK, job)
+	})
+
+	// HSM state change notifications. HSM can't authenticate its callbacks so neither can this, all a notification
+	// can do though is have the named components read again from SLS and HSM sooner than the next run would.
+	apiV1.POST("/manager/scn", func(c *gin.Context) {
+		if !*hsmSCNSubscribe {
+			sendProblem(c, http.StatusForbidden, "not subscribed to HSM state change notifications")
+			return
+		}
+
+		var payload sm.SCNPayload
+		if err := c.ShouldBindJSON(&payload); err != nil {
+			sendProblem(c, http.StatusBadRequest, fmt.Sprintf("failed to decode state change notification: %s", err))
+			return
+		}
+
+		logger.Debug("Received HSM state change notification", zap.Any("payload", payload))
+		var componentIDs []string
+		for _, componentID := range payload.Components {
+			if normalizedID := base.VerifyNormalizeCompID(componentID); normalizedID != "" {
+				componentIDs = append(componentIDs, normalizedID)
+			} else {
+				logger.Warn("Ignoring invalid component in HSM state change notification",
+					zap.String("componentID", componentID))
+			}
+		}
+		if len(componentIDs) > 0 {
+			queueSCNComponents(componentIDs)
+		}
+
+		c.JSON(http.StatusNoContent, nil)
+	})
+
+	// Compute what the true up loop would do without doing any of it.
+	apiV1.POST("/manager/plan", func(c *gin.Context) {
+		// A plan is as much work as a run so it waits its turn like one, but rather than pile up behind the loop the
+		// caller is told to come back later.
+		trueUpMtx.Lock()
+		busy := trueUpInProgress || len(trueUpRunNow) > 0 || !trueUpRunMtx.TryLock()
 		trueUpMtx.Unlock()
+		if busy {
+			sendProblem(c, http.StatusConflict, "a true up run or plan is already in progress")
+			return
+		}
+		defer trueUpRunMtx.Unlock()
+
+		plan, err := runTrueUp(true, nil)
+		if err != nil {
+			logger.Error("Failed to compute plan!", zap.Error(err))
+			sendProblem(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		c.JSON(http.StatusOK, plan)
+	})
+
+	// DS records to hand to the operator of the parent zone.
+	apiV1.GET("/manager/dnssec/ds", func(c *gin.Context) {
+		var zoneNames []string
+		if zone := c.Query("zone"); zone != "" {
+			zoneNames = append(zoneNames, common.MakeDomainCanonical(zone))
+		} else {
+			zones, err := pdns.Zones.List()
+			recordPowerDNSResult(err)
+			if err != nil {
+				sendProblem(c, http.StatusInternalServerError, fmt.Sprintf("failed to list zones: %s", err))
+				return
+			}
+			for _, zone := range zones {
+				if zone.Name != nil && zone.DNSsec != nil && *zone.DNSsec {
+					zoneNames = append(zoneNames, *zone.Name)
+				}
+			}
+		}
+
+		dsRecords, err := getDSRecords(zoneNames)
+		if err != nil {
+			var pdnsErr *powerdns.Error
+			if errors.As(err, &pdnsErr) && pdnsErr.StatusCode == http.StatusNotFound {
+				sendProblem(c, http.StatusNotFound, err.Error())
+				return
+			}
+			logger.Error("Failed to get DS records!", zap.Error(err))
+			sendProblem(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		c.JSON(http.StatusOK, dsRecords)
+	})
+
+	// Keys loaded from the key directory.
+	apiV1.GET("/manager/keys", func(c *gin.Context) {
+		c.JSON(http.StatusOK, getKeyStatuses())
+	})
+
+	// Conflicts between record sources found by the last full run.
+	apiV1.GET("/manager/conflicts", func(c *gin.Context) {
+		c.JSON(http.StatusOK, getLastConflicts())
+	})
+
+	// Operator defined records.
+	apiV1.GET("/manager/records", func(c *gin.Context) {
+		records, err := getManualRecords()
+		if err != nil {
+			logger.Error("Failed to get records!", zap.Error(err))
+			sendProblem(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		c.JSON(http.StatusOK, records)
+	})
+	apiV1.POST("/manager/records", func(c *gin.Context) {
+		var record ManualRecord
+		if err := c.ShouldBindJSON(&record); err != nil {
+			sendProblem(c, http.StatusBadRequest, fmt.Sprintf("failed to decode record: %s", err))
+			return
+		}
+
+		if err := putManualRecord(record, false); err != nil {
+			sendRecordProblem(c, err)
+			return
+		}
+		queueRecordsTrueUp()
+
+		c.JSON(http.StatusCreated, nil)
+	})
+	apiV1.GET("/manager/records/:name/:type", func(c *gin.Context) {
+		records, err := getManualRecords()
+		if err != nil {
+			logger.Error("Failed to get records!", zap.Error(err))
+			sendProblem(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		name, rrType := getRecordParams(c)
+		i := findManualRecord(records, name, rrType)
+		if i < 0 {
+			sendProblem(c, http.StatusNotFound, fmt.Sprintf("no %s record for %s", rrType, name))
+			return
+		}
+
+		c.JSON(http.StatusOK, records[i])
+	})
+	apiV1.PUT("/manager/records/:name/:type", func(c *gin.Context) {
+		var record ManualRecord
+		if err := c.ShouldBindJSON(&record); err != nil {
+			sendProblem(c, http.StatusBadRequest, fmt.Sprintf("failed to decode record: %s", err))
+			return
+		}
+
+		// The path says which record this is.
+		record.Name, record.Type = getRecordParams(c)
+		if err := putManualRecord(record, true); err != nil {
+			sendRecordProblem(c, err)
+			return
+		}
+		queueRecordsTrueUp()
+
+		c.JSON(http.StatusNoContent, nil)
+	})
+	apiV1.DELETE("/manager/records/:name/:type", func(c *gin.Context) {
+		name, rrType := getRecordParams(c)
+		if err := deleteManualRecord(name, rrType); err != nil {
+			sendRecordProblem(c, err)
+			return
+		}
+		queueRecordsTrueUp()
+
+		c.JSON(http.StatusNoContent, nil)
 	})
 
 	// Run the router.
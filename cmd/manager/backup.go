@@ -0,0 +1,428 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joeig/go-powerdns/v2"
+	"go.uber.org/zap"
+)
+
+// BackupVersion is the version of the backup layout, restore refuses anything it doesn't know.
+const BackupVersion = 1
+
+const backupManifestFile = "manifest.json"
+
+// Metadata kinds PowerDNS won't let the API set, they are all restored from the zone itself instead.
+var backupReadOnlyMetadataKinds = map[string]bool{
+	"API-RECTIFY":      true,
+	"AXFR-MASTER-TSIG": true,
+	"LUA-AXFR-SCRIPT":  true,
+	"NSEC3NARROW":      true,
+	"NSEC3PARAM":       true,
+	"PRESIGNED":        true,
+	"SOA-EDIT-API":     true,
+	"TSIG-ALLOW-AXFR":  true,
+}
+
+// BackupManifest describes everything in a backup. Private keys are in here so the backup has to be kept secret.
+type BackupManifest struct {
+	Version  int                `json:"version"`
+	Created  time.Time          `json:"created"`
+	Zones    []BackupZone       `json:"zones"`
+	TSIGKeys []powerdns.TSIGKey `json:"tsig_keys"`
+}
+
+// BackupZone is a single zone in a backup. The zone file is the PowerDNS export of the zone in BIND format and is only
+// there for people, restores use the data file which is the zone as the API returns it, including RRset comments.
+type BackupZone struct {
+	Name       string               `json:"name"`
+	ZoneFile   string               `json:"zone_file"`
+	DataFile   string               `json:"data_file"`
+	Cryptokeys []powerdns.Cryptokey `json:"cryptokeys"`
+	Metadata   []zoneMetadata       `json:"metadata"`
+}
+
+// writeBackupFile writes the file only readable by the owner as most of what is in a backup is either secret or useful
+// to an attacker.
+func writeBackupFile(path string, data []byte) error {
+	err := ioutil.WriteFile(path, data, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// backupZone exports a single zone along with its keys and metadata into the backup directory. It returns the TSIG keys
+// the zone uses, only the full zone has them, the zone list leaves them out.
+func backupZone(backupDir string, zoneName string) (backupZone BackupZone, tsigKeyIDs []string, err error) {
+	backupZone = BackupZone{
+		Name:       zoneName,
+		ZoneFile:   filepath.Join("zones", getZoneFileName(zoneName, "zone")),
+		DataFile:   filepath.Join("zones", getZoneFileName(zoneName, "json")),
+		Cryptokeys: []powerdns.Cryptokey{},
+		Metadata:   []zoneMetadata{},
+	}
+
+	zone, err := pdns.Zones.Get(zoneName)
+	recordPowerDNSResult(err)
+	if err != nil {
+		err = fmt.Errorf("failed to get zone: %w", err)
+		return
+	}
+	tsigKeyIDs = append(append(tsigKeyIDs, zone.MasterTSIGKeyIDs...), zone.SlaveTSIGKeyIDs...)
+
+	zoneData, err := json.MarshalIndent(zone, "", "  ")
+	if err != nil {
+		err = fmt.Errorf("failed to marshal zone: %w", err)
+		return
+	}
+	err = writeBackupFile(filepath.Join(backupDir, backupZone.DataFile), zoneData)
+	if err != nil {
+		return
+	}
+
+	export, err := pdns.Zones.Export(zoneName)
+	recordPowerDNSResult(err)
+	if err != nil {
+		err = fmt.Errorf("failed to export zone: %w", err)
+		return
+	}
+	err = writeBackupFile(filepath.Join(backupDir, backupZone.ZoneFile), []byte(export))
+	if err != nil {
+		return
+	}
+
+	// The list of keys doesn't include the private keys, they have to be fetched one at a time.
+	cryptokeys, err := pdns.Cryptokeys.List(zoneName)
+	recordPowerDNSResult(err)
+	if err != nil {
+		err = fmt.Errorf("failed to list cryptokeys: %w", err)
+		return
+	}
+	for _, cryptokey := range cryptokeys {
+		if cryptokey.ID == nil {
+			continue
+		}
+
+		var fullCryptokey *powerdns.Cryptokey
+		fullCryptokey, err = pdns.Cryptokeys.Get(zoneName, *cryptokey.ID)
+		recordPowerDNSResult(err)
+		if err != nil {
+			err = fmt.Errorf("failed to get cryptokey %d: %w", *cryptokey.ID, err)
+			return
+		}
+		backupZone.Cryptokeys = append(backupZone.Cryptokeys, *fullCryptokey)
+	}
+
+	err = doPowerDNSRequest(http.MethodGet, fmt.Sprintf("zones/%s/metadata", zoneName), nil, &backupZone.Metadata)
+	if err != nil {
+		err = fmt.Errorf("failed to get metadata: %w", err)
+	}
+
+	return
+}
+
+// runBackup backs up every zone the manager owns, with its cryptokeys and metadata, and the TSIG keys those zones use
+// into a new directory under parentDir named for the time of the backup. It returns the path of that directory.
+func runBackup(parentDir string) (backupDir string, err error) {
+	manifest := BackupManifest{
+		Version:  BackupVersion,
+		Created:  time.Now().UTC(),
+		Zones:    []BackupZone{},
+		TSIGKeys: []powerdns.TSIGKey{},
+	}
+
+	backupDir = filepath.Join(parentDir, manifest.Created.Format("20060102T150405Z"))
+	err = os.MkdirAll(filepath.Join(backupDir, "zones"), 0700)
+	if err != nil {
+		err = fmt.Errorf("failed to create backup directory: %w", err)
+		return
+	}
+
+	zones, err := pdns.Zones.List()
+	recordPowerDNSResult(err)
+	if err != nil {
+		err = fmt.Errorf("failed to list zones: %w", err)
+		return
+	}
+
+	tsigKeyIDs := make(map[string]bool)
+	for _, zone := range zones {
+		if zone.Name == nil || !isManagerOwnedZone(zone) {
+			continue
+		}
+
+		var backupZoneEntry BackupZone
+		var zoneTSIGKeyIDs []string
+		backupZoneEntry, zoneTSIGKeyIDs, err = backupZone(backupDir, *zone.Name)
+		if err != nil {
+			err = fmt.Errorf("failed to back up zone %s: %w", *zone.Name, err)
+			return
+		}
+		manifest.Zones = append(manifest.Zones, backupZoneEntry)
+
+		for _, tsigKeyID := range zoneTSIGKeyIDs {
+			tsigKeyIDs[tsigKeyID] = true
+		}
+
+		logger.Info("Backed up zone", zap.String("zoneName", *zone.Name))
+	}
+
+	for tsigKeyID := range tsigKeyIDs {
+		var tsigKey *powerdns.TSIGKey
+		tsigKey, err = pdns.TSIGKeys.Get(tsigKeyID)
+		recordPowerDNSResult(err)
+		if err != nil {
+			err = fmt.Errorf("failed to get TSIG key %s: %w", tsigKeyID, err)
+			return
+		}
+		manifest.TSIGKeys = append(manifest.TSIGKeys, *tsigKey)
+	}
+
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		err = fmt.Errorf("failed to marshal manifest: %w", err)
+		return
+	}
+
+	// The manifest goes last so a backup without one is known to be incomplete.
+	err = writeBackupFile(filepath.Join(backupDir, backupManifestFile), manifestData)
+
+	return
+}
+
+// restoreTSIGKey adds the TSIG key, replacing it if there is already one with the same name.
+func restoreTSIGKey(tsigKey powerdns.TSIGKey) error {
+	if tsigKey.Name == nil {
+		return fmt.Errorf("TSIG key has no name")
+	}
+
+	newTSIGKey := &powerdns.TSIGKey{
+		Name:      tsigKey.Name,
+		Algorithm: tsigKey.Algorithm,
+		Key:       tsigKey.Key,
+	}
+
+	_, err := pdns.TSIGKeys.Get(*tsigKey.Name)
+	recordPowerDNSResult(err)
+	if err == nil {
+		_, err = pdns.TSIGKeys.Replace(*tsigKey.Name, newTSIGKey)
+	} else {
+		_, err = pdns.TSIGKeys.Add(newTSIGKey)
+	}
+	recordPowerDNSResult(err)
+	if err != nil {
+		return fmt.Errorf("failed to restore TSIG key %s: %w", *tsigKey.Name, err)
+	}
+
+	return nil
+}
+
+// restoreZone recreates the zone exactly as it was backed up. Cryptokeys get new IDs when they are added so the
+// manager's own record of its keys is updated to match.
+func restoreZone(backupDir string, backupZone BackupZone) (err error) {
+	zoneData, err := ioutil.ReadFile(filepath.Join(backupDir, backupZone.DataFile))
+	if err != nil {
+		err = fmt.Errorf("failed to read zone data: %w", err)
+		return
+	}
+	var backedUpZone powerdns.Zone
+	err = json.Unmarshal(zoneData, &backedUpZone)
+	if err != nil {
+		err = fmt.Errorf("failed to unmarshal zone data: %w", err)
+		return
+	}
+
+	// The NS RRset is part of the RRsets so the nameservers must be left out. DNSSEC settings can only be applied once
+	// the keys are back.
+	var rrSets []powerdns.RRset
+	for _, rrSet := range backedUpZone.RRsets {
+		rrSet.ChangeType = nil
+		rrSets = append(rrSets, rrSet)
+	}
+
+	zone := &powerdns.Zone{
+		Name:             powerdns.String(backupZone.Name),
+		Kind:             backedUpZone.Kind,
+		DNSsec:           powerdns.Bool(false),
+		Masters:          backedUpZone.Masters,
+		SOAEdit:          backedUpZone.SOAEdit,
+		SOAEditAPI:       backedUpZone.SOAEditAPI,
+		APIRectify:       backedUpZone.APIRectify,
+		Account:          backedUpZone.Account,
+		MasterTSIGKeyIDs: backedUpZone.MasterTSIGKeyIDs,
+		SlaveTSIGKeyIDs:  backedUpZone.SlaveTSIGKeyIDs,
+		RRsets:           rrSets,
+	}
+	_, err = pdns.Zones.Add(zone)
+	recordPowerDNSResult(err)
+	if err != nil {
+		err = fmt.Errorf("failed to add zone: %w", err)
+		return
+	}
+
+	newKeyIDs := make(map[string]string)
+	for _, cryptokey := range backupZone.Cryptokeys {
+		var newCryptokey *powerdns.Cryptokey
+		newCryptokey, err = pdns.Cryptokeys.Add(backupZone.Name, &powerdns.Cryptokey{
+			KeyType:    cryptokey.KeyType,
+			Active:     cryptokey.Active,
+			Privatekey: cryptokey.Privatekey,
+		})
+		recordPowerDNSResult(err)
+		if err != nil {
+			err = fmt.Errorf("failed to add cryptokey: %w", err)
+			return
+		}
+		if cryptokey.ID != nil && newCryptokey.ID != nil {
+			newKeyIDs[strconv.FormatUint(*cryptokey.ID, 10)] = strconv.FormatUint(*newCryptokey.ID, 10)
+		}
+	}
+
+	if backedUpZone.Nsec3Param != nil && *backedUpZone.Nsec3Param != "" {
+		err = pdns.Zones.Change(backupZone.Name, &powerdns.Zone{
+			Nsec3Param:  backedUpZone.Nsec3Param,
+			Nsec3Narrow: backedUpZone.Nsec3Narrow,
+		})
+		recordPowerDNSResult(err)
+		if err != nil {
+			err = fmt.Errorf("failed to set NSEC3PARAM: %w", err)
+			return
+		}
+	}
+
+	for _, metadata := range backupZone.Metadata {
+		if backupReadOnlyMetadataKinds[metadata.Kind] {
+			continue
+		}
+
+		if metadata.Kind == dnssecKeyStateMetadataKind {
+			var entries []string
+			for _, entry := range metadata.Metadata {
+				fields := strings.Fields(entry)
+				if len(fields) > 0 {
+					newKeyID, found := newKeyIDs[fields[0]]
+					if !found {
+						continue
+					}
+					fields[0] = newKeyID
+				}
+				entries = append(entries, strings.Join(fields, " "))
+			}
+			metadata.Metadata = entries
+		}
+
+		err = doPowerDNSRequest(http.MethodPut, fmt.Sprintf("zones/%s/metadata/%s", backupZone.Name, metadata.Kind),
+			metadata, nil)
+		if err != nil {
+			err = fmt.Errorf("failed to restore %s metadata: %w", metadata.Kind, err)
+			return
+		}
+	}
+
+	if len(backupZone.Cryptokeys) > 0 {
+		_, err = pdns.Zones.Rectify(backupZone.Name)
+		recordPowerDNSResult(err)
+		if err != nil {
+			err = fmt.Errorf("failed to rectify zone: %w", err)
+		}
+	}
+
+	return
+}
+
+// runRestore restores the backup in backupDir. It is meant for an empty PowerDNS, zones that already exist are left
+// alone so a restore never overwrites anything. Every zone is attempted even if some fail.
+func runRestore(backupDir string) (err error) {
+	manifestData, err := ioutil.ReadFile(filepath.Join(backupDir, backupManifestFile))
+	if err != nil {
+		return fmt.Errorf("failed to read manifest, the backup may be incomplete: %w", err)
+	}
+
+	var manifest BackupManifest
+	err = json.Unmarshal(manifestData, &manifest)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal manifest: %w", err)
+	}
+	if manifest.Version != BackupVersion {
+		return fmt.Errorf("backup version %d is not supported, expected %d", manifest.Version, BackupVersion)
+	}
+
+	logger.Info("Restoring backup", zap.String("backupDir", backupDir), zap.Time("created", manifest.Created),
+		zap.Int("numZones", len(manifest.Zones)), zap.Int("numTSIGKeys", len(manifest.TSIGKeys)))
+
+	// The zones refer to the TSIG keys so they have to go first.
+	for _, tsigKey := range manifest.TSIGKeys {
+		err = restoreTSIGKey(tsigKey)
+		if err != nil {
+			return
+		}
+	}
+
+	var failedZones []string
+	for _, backupZone := range manifest.Zones {
+		zoneLogger := logger.With(zap.String("zoneName", backupZone.Name))
+
+		_, getErr := pdns.Zones.Get(backupZone.Name)
+		recordPowerDNSResult(getErr)
+		if getErr == nil {
+			zoneLogger.Warn("Zone already exists, not restoring it.")
+			continue
+		}
+		pdnsErr, ok := getErr.(*powerdns.Error)
+		if !ok || pdnsErr.StatusCode != http.StatusNotFound {
+			zoneLogger.Error("Failed to check if zone exists!", zap.Error(getErr))
+			failedZones = append(failedZones, backupZone.Name)
+			continue
+		}
+
+		restoreErr := restoreZone(backupDir, backupZone)
+		if restoreErr != nil {
+			zoneLogger.Error("Failed to restore zone!", zap.Error(restoreErr))
+			failedZones = append(failedZones, backupZone.Name)
+			continue
+		}
+
+		zoneLogger.Info("Restored zone")
+	}
+
+	if len(failedZones) > 0 {
+		return fmt.Errorf("failed to restore zones: %s", strings.Join(failedZones, ", "))
+	}
+
+	return nil
+}
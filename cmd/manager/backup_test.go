@@ -0,0 +1,216 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Cray-HPE/cray-powerdns-manager/internal/common"
+	"github.com/hashicorp/go-retryablehttp"
+	"github.com/joeig/go-powerdns/v2"
+)
+
+// fakePowerDNS is just enough of the PowerDNS API for backups and restores. Like the real one its zone list leaves out
+// the TSIG keys of each zone.
+type fakePowerDNS struct {
+	mtx      sync.Mutex
+	zones    map[string]powerdns.Zone
+	tsigKeys map[string]powerdns.TSIGKey
+	metadata map[string][]zoneMetadata
+}
+
+func newFakePowerDNS() *fakePowerDNS {
+	return &fakePowerDNS{
+		zones:    make(map[string]powerdns.Zone),
+		tsigKeys: make(map[string]powerdns.TSIGKey),
+		metadata: make(map[string][]zoneMetadata),
+	}
+}
+
+func (fake *fakePowerDNS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	fake.mtx.Lock()
+	defer fake.mtx.Unlock()
+
+	reply := func(statusCode int, v interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(statusCode)
+		_ = json.NewEncoder(w).Encode(v)
+	}
+	notFound := func() {
+		reply(http.StatusNotFound, map[string]string{"error": "Not Found"})
+	}
+
+	path := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/v1/servers/localhost/"), "/")
+	if len(path) > 1 {
+		path[1] = common.MakeDomainCanonical(path[1])
+	}
+
+	switch {
+	case r.Method == http.MethodGet && len(path) == 1 && path[0] == "zones":
+		zones := []powerdns.Zone{}
+		for _, zone := range fake.zones {
+			zones = append(zones, powerdns.Zone{Name: zone.Name, Kind: zone.Kind, Account: zone.Account})
+		}
+		reply(http.StatusOK, zones)
+	case r.Method == http.MethodPost && len(path) == 1 && path[0] == "zones":
+		var zone powerdns.Zone
+		_ = json.NewDecoder(r.Body).Decode(&zone)
+		zone.Name = powerdns.String(common.MakeDomainCanonical(*zone.Name))
+		fake.zones[*zone.Name] = zone
+		reply(http.StatusCreated, zone)
+	case path[0] == "zones":
+		zone, found := fake.zones[path[1]]
+		if !found {
+			notFound()
+			return
+		}
+
+		switch {
+		case r.Method == http.MethodGet && len(path) == 2:
+			reply(http.StatusOK, zone)
+		case r.Method == http.MethodGet && len(path) == 3 && path[2] == "export":
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte(*zone.Name + "\t3600\tIN\tSOA\tns1.example.com. admin.example.com. 1 2 3 4 5\n"))
+		case r.Method == http.MethodGet && len(path) == 3 && path[2] == "cryptokeys":
+			reply(http.StatusOK, []powerdns.Cryptokey{})
+		case r.Method == http.MethodGet && len(path) == 3 && path[2] == "metadata":
+			reply(http.StatusOK, append([]zoneMetadata{}, fake.metadata[path[1]]...))
+		case r.Method == http.MethodPut && len(path) == 4 && path[2] == "metadata":
+			var metadata zoneMetadata
+			_ = json.NewDecoder(r.Body).Decode(&metadata)
+			fake.metadata[path[1]] = append(fake.metadata[path[1]], metadata)
+			reply(http.StatusOK, metadata)
+		default:
+			notFound()
+		}
+	case path[0] == "tsigkeys":
+		var tsigKey powerdns.TSIGKey
+		if r.Method == http.MethodPost || r.Method == http.MethodPut {
+			_ = json.NewDecoder(r.Body).Decode(&tsigKey)
+			tsigKey.ID = tsigKey.Name
+			fake.tsigKeys[*tsigKey.Name] = tsigKey
+			reply(http.StatusCreated, tsigKey)
+			return
+		}
+
+		tsigKey, found := fake.tsigKeys[strings.TrimSuffix(path[1], ".")]
+		if r.Method != http.MethodGet || !found {
+			notFound()
+			return
+		}
+		reply(http.StatusOK, tsigKey)
+	default:
+		notFound()
+	}
+}
+
+// useFakePowerDNS points the PowerDNS clients at the fake until the test ends.
+func useFakePowerDNS(t *testing.T, fake *fakePowerDNS) {
+	server := httptest.NewServer(fake)
+
+	oldPDNS, oldPDNSURL, oldHTTPClient, oldCtx := pdns, *pdnsURL, httpClient, ctx
+	t.Cleanup(func() {
+		server.Close()
+		pdns, *pdnsURL, httpClient, ctx = oldPDNS, oldPDNSURL, oldHTTPClient, oldCtx
+	})
+
+	*pdnsURL = server.URL
+	pdns = powerdns.NewClient(server.URL, "localhost", map[string]string{"X-API-Key": *pdnsAPIKey},
+		server.Client())
+	httpClient = retryablehttp.NewClient()
+	httpClient.Logger = nil
+	httpClient.RetryMax = 0
+	ctx = context.Background()
+}
+
+func TestBackupAndRestore(t *testing.T) {
+	source := newFakePowerDNS()
+	source.zones["example.com."] = powerdns.Zone{
+		Name:             powerdns.String("example.com."),
+		Kind:             powerdns.ZoneKindPtr(powerdns.MasterZoneKind),
+		Account:          powerdns.String(common.ManagerAccount),
+		MasterTSIGKeyIDs: []string{"transfer"},
+	}
+	source.zones["other.com."] = powerdns.Zone{
+		Name:             powerdns.String("other.com."),
+		Kind:             powerdns.ZoneKindPtr(powerdns.MasterZoneKind),
+		MasterTSIGKeyIDs: []string{"other"},
+	}
+	source.tsigKeys["transfer"] = powerdns.TSIGKey{
+		Name:      powerdns.String("transfer"),
+		ID:        powerdns.String("transfer"),
+		Algorithm: powerdns.String("hmac-sha256"),
+		Key:       powerdns.String("c2VjcmV0"),
+	}
+	source.tsigKeys["other"] = powerdns.TSIGKey{
+		Name:      powerdns.String("other"),
+		ID:        powerdns.String("other"),
+		Algorithm: powerdns.String("hmac-sha256"),
+		Key:       powerdns.String("b3RoZXI="),
+	}
+	source.metadata["example.com."] = []zoneMetadata{
+		{Kind: tsigKeyOwnershipMetadataKind, Metadata: []string{"transfer."}},
+	}
+
+	useFakePowerDNS(t, source)
+	backupPath, err := runBackup(t.TempDir())
+	if err != nil {
+		t.Fatalf("runBackup() error = %v", err)
+	}
+
+	restored := newFakePowerDNS()
+	useFakePowerDNS(t, restored)
+	err = runRestore(backupPath)
+	if err != nil {
+		t.Fatalf("runRestore() error = %v", err)
+	}
+
+	// Only the zone the manager owns is backed up, along with the TSIG key it uses.
+	if len(restored.zones) != 1 {
+		t.Fatalf("runRestore() restored %d zones, want 1", len(restored.zones))
+	}
+	zone := restored.zones["example.com."]
+	if !reflect.DeepEqual(zone.MasterTSIGKeyIDs, []string{"transfer"}) {
+		t.Errorf("runRestore() zone TSIG keys = %v, want [transfer]", zone.MasterTSIGKeyIDs)
+	}
+	if _, found := restored.tsigKeys["other"]; found || len(restored.tsigKeys) != 1 {
+		t.Errorf("runRestore() restored TSIG keys %v, want only transfer", restored.tsigKeys)
+	}
+	if tsigKey := restored.tsigKeys["transfer"]; tsigKey.Key == nil || *tsigKey.Key != "c2VjcmV0" {
+		t.Errorf("runRestore() TSIG key = %+v, want the backed up key", tsigKey)
+	}
+	if !reflect.DeepEqual(restored.metadata["example.com."], source.metadata["example.com."]) {
+		t.Errorf("runRestore() metadata = %v, want %v", restored.metadata["example.com."],
+			source.metadata["example.com."])
+	}
+}
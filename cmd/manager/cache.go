@@ -0,0 +1,225 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/Cray-HPE/cray-powerdns-manager/internal/common"
+	sls_common "github.com/Cray-HPE/hms-sls/pkg/sls-common"
+	"github.com/joeig/go-powerdns/v2"
+	"go.uber.org/zap"
+)
+
+// trueUpCache is what the last true up run computed and saw, used by incremental runs to avoid fetching every zone and
+// diffing every RRset each time. It is only ever touched by the true up loop so there is no locking.
+type trueUpCache struct {
+	valid               bool
+	lastFullResync      time.Time
+	networksFingerprint string
+
+	// inputsFingerprint covers everything else the desired state is built from, when it hasn't changed neither has
+	// the desired state.
+	inputsFingerprint string
+
+	// desired is the last complete desired state keyed by RRset name and type.
+	desired map[common.RRsetKey]powerdns.RRset
+
+	// zones is the state of every master zone after the last run's changes were applied.
+	zones        []*powerdns.Zone
+	reverseZones []*powerdns.Zone
+}
+
+var stateCache trueUpCache
+
+// getFingerprint returns a hash of the JSON encoding of v, empty if it can't be encoded.
+func getFingerprint(v interface{}) string {
+	vBytes, err := json.Marshal(v)
+	if err != nil {
+		// Can't happen as everything fingerprinted was unmarshalled from JSON, but, if it does an empty fingerprint
+		// never matches.
+		return ""
+	}
+
+	hash := sha256.Sum256(vBytes)
+	return hex.EncodeToString(hash[:])
+}
+
+// getNetworksFingerprint returns a hash of the SLS networks and static zones. A change to either can mean new zones so
+// it always forces a full resync.
+func getNetworksFingerprint(networks []sls_common.Network, staticZoneNames []string) string {
+	return getFingerprint([]interface{}{networks, staticZoneNames})
+}
+
+// isUnchanged returns true if an incremental run would build exactly the desired state the cache already has.
+func (cache *trueUpCache) isUnchanged(inputsFingerprint string) bool {
+	return cache.valid && inputsFingerprint != "" && inputsFingerprint == cache.inputsFingerprint
+}
+
+// hasZones returns true if the cached zones are still every zone there should be, the full resync interval aside.
+func (cache *trueUpCache) hasZones(networksFingerprint string) bool {
+	return cache.valid && networksFingerprint != "" && networksFingerprint == cache.networksFingerprint
+}
+
+// needsFullResync returns true if the cache can't be trusted for an incremental run.
+func (cache *trueUpCache) needsFullResync(networksFingerprint string) bool {
+	return !cache.hasZones(networksFingerprint) ||
+		time.Since(cache.lastFullResync) >= time.Duration(*fullResyncInterval)*time.Second
+}
+
+func (cache *trueUpCache) invalidate() {
+	cache.valid = false
+	cache.inputsFingerprint = ""
+	cache.desired = nil
+	cache.zones = nil
+	cache.reverseZones = nil
+}
+
+// diffDesired compares the desired RRsets with the cached desired state. Changed contains everything new or different,
+// removed everything no longer desired.
+func (cache *trueUpCache) diffDesired(rrSets []powerdns.RRset) (changed []powerdns.RRset,
+	removed []powerdns.RRset) {
+	desiredRRSetMap := getDesiredRRSetMap(rrSets)
+
+	for key, rrSet := range desiredRRSetMap {
+		cachedRRset, found := cache.desired[key]
+		if !found || !common.RRsetsEqual(rrSet, cachedRRset) || !sameOwnershipSource(rrSet, cachedRRset) {
+			changed = append(changed, rrSet)
+		}
+	}
+
+	for key, cachedRRset := range cache.desired {
+		if _, found := desiredRRSetMap[key]; !found {
+			removed = append(removed, cachedRRset)
+		}
+	}
+
+	return
+}
+
+// update records the outcome of a run. The zones are updated in place with every change the run made so they match
+// what is now in PowerDNS without having to fetch them again.
+func (cache *trueUpCache) update(networksFingerprint string, inputsFingerprint string, rrSets []powerdns.RRset,
+	zones []*powerdns.Zone, reverseZones []*powerdns.Zone, plan *Plan, fullResync bool) {
+	for _, zonePlan := range plan.Zones {
+		if zonePlan.Error != "" {
+			// No way to know what state this zone is in now, start again next time.
+			logger.Debug("Zone failed to true up, invalidating cache.", zap.String("zone", zonePlan.Name))
+			cache.invalidate()
+			return
+		}
+	}
+
+	applyPlanToZones(zones, plan)
+
+	cache.desired = getDesiredRRSetMap(rrSets)
+	cache.zones = zones
+	cache.reverseZones = reverseZones
+	cache.networksFingerprint = networksFingerprint
+	cache.inputsFingerprint = inputsFingerprint
+	if fullResync {
+		cache.lastFullResync = time.Now()
+	}
+	cache.valid = true
+}
+
+// applyPlan updates the cached zones with changes made outside of a full or incremental run.
+func (cache *trueUpCache) applyPlan(plan *Plan) {
+	if !cache.valid {
+		return
+	}
+
+	for _, zonePlan := range plan.Zones {
+		if zonePlan.Error != "" {
+			cache.invalidate()
+			return
+		}
+	}
+
+	applyPlanToZones(cache.zones, plan)
+}
+
+// applyPlanToZones makes the RRsets in the zones look as they would after the plan was patched into PowerDNS.
+func applyPlanToZones(zones []*powerdns.Zone, plan *Plan) {
+	for _, zone := range zones {
+		zonePlan, found := plan.zoneMap[common.MakeDomainCanonical(*zone.Name)]
+		if !found || !zonePlan.HasRRsetChanges() {
+			continue
+		}
+
+		var changedRRSets []powerdns.RRset
+		changedRRSets = append(changedRRSets, zonePlan.Deletes...)
+		changedRRSets = append(changedRRSets, zonePlan.Creates...)
+		changedRRSets = append(changedRRSets, zonePlan.Replaces...)
+
+		var rrSets []powerdns.RRset
+	zoneRRsets:
+		for _, zoneRRset := range zone.RRsets {
+			for _, changedRRset := range changedRRSets {
+				if *zoneRRset.Name == *changedRRset.Name && *zoneRRset.Type == *changedRRset.Type {
+					continue zoneRRsets
+				}
+			}
+			rrSets = append(rrSets, zoneRRset)
+		}
+		rrSets = append(rrSets, zonePlan.Creates...)
+		rrSets = append(rrSets, zonePlan.Replaces...)
+
+		zone.RRsets = rrSets
+	}
+}
+
+// sameOwnershipSource returns true if both RRsets were generated from the same SLS or HSM object.
+func sameOwnershipSource(a powerdns.RRset, b powerdns.RRset) bool {
+	aOwnership, _ := common.GetRRsetOwnership(a)
+	bOwnership, _ := common.GetRRsetOwnership(b)
+
+	return aOwnership.String() == bOwnership.String()
+}
+
+// planRemovedRRSets records a delete for every RRset in the zones that the manager owns and matches one that is no
+// longer desired.
+func planRemovedRRSets(removed []powerdns.RRset, zones []*powerdns.Zone, plan *Plan) {
+	for _, removedRRset := range removed {
+		for _, zone := range zones {
+			for _, zoneRRset := range zone.RRsets {
+				if *zoneRRset.Name != *removedRRset.Name || *zoneRRset.Type != *removedRRset.Type ||
+					!isRemovableRRset(zoneRRset) {
+					continue
+				}
+
+				zoneRRset.ChangeType = powerdns.ChangeTypePtr(powerdns.ChangeTypeDelete)
+				zonePlan := plan.getZonePlan(*zone.Name)
+				zonePlan.Deletes = append(zonePlan.Deletes, zoneRRset)
+				logger.Info("RRset is no longer desired and needs to be removed, adding to patch list.",
+					zap.Any("zoneRRset", zoneRRset))
+			}
+		}
+	}
+}
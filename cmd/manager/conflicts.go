@@ -0,0 +1,235 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package main
+
+import (
+	"sync"
+
+	"github.com/Cray-HPE/cray-powerdns-manager/internal/common"
+	"github.com/joeig/go-powerdns/v2"
+)
+
+// ConflictReason is why two RRsets couldn't both be kept.
+type ConflictReason string
+
+const (
+	// ConflictReasonOverride is a generated RRset replaced by one from the static records file or the API.
+	ConflictReasonOverride ConflictReason = "override"
+	// ConflictReasonCNAME is a CNAME and another type at the same name.
+	ConflictReasonCNAME ConflictReason = "cname"
+	// ConflictReasonRecords is the same name and type with different records, e.g. SLS and HSM disagreeing on an IP.
+	ConflictReasonRecords ConflictReason = "records"
+	// ConflictReasonPTR is a reverse name pointed at two different names.
+	ConflictReasonPTR ConflictReason = "ptr"
+)
+
+// Which source wins when generated RRsets conflict.
+const (
+	ConflictPrecedenceSLS = common.OwnerSourceSLS
+	ConflictPrecedenceHSM = common.OwnerSourceHSM
+)
+
+var (
+	// lastConflicts are the conflicts found by the last full true up run that wasn't a dry run.
+	lastConflicts    []RecordConflict
+	lastConflictsMtx sync.Mutex
+)
+
+// getConflictRank returns how strongly an RRset holds on to its name, the source given precedence beats the others.
+func getConflictRank(rrSet powerdns.RRset) int {
+	ownership, _ := common.GetRRsetOwnership(rrSet)
+	if ownership.Source == *conflictPrecedence {
+		return 1
+	}
+
+	return 0
+}
+
+// getConflictReason returns why the two RRsets at the same name can't both be kept.
+func getConflictReason(a powerdns.RRset, b powerdns.RRset) ConflictReason {
+	if *a.Type != *b.Type {
+		return ConflictReasonCNAME
+	}
+	if *a.Type == powerdns.RRTypePTR {
+		return ConflictReasonPTR
+	}
+
+	return ConflictReasonRecords
+}
+
+// newRecordConflict describes the dropped RRset losing out to the kept one.
+func newRecordConflict(kept powerdns.RRset, dropped powerdns.RRset) RecordConflict {
+	keptOwnership, _ := common.GetRRsetOwnership(kept)
+	droppedOwnership, _ := common.GetRRsetOwnership(dropped)
+
+	return RecordConflict{
+		Name:    *dropped.Name,
+		Type:    string(*dropped.Type),
+		Reason:  getConflictReason(kept, dropped),
+		Kept:    keptOwnership.String(),
+		Dropped: droppedOwnership.String(),
+	}
+}
+
+// resolveConflicts drops the generated RRsets that can't be kept alongside another one and records each in the plan.
+// Two RRsets conflict when they have the same name and type but different records, or when one of them is a CNAME. The
+// RRset from the source given precedence is kept, between RRsets from the same source the later one is kept. Exact
+// duplicates aren't conflicts, the first one is kept.
+func resolveConflicts(rrSets []powerdns.RRset, plan *Plan) (resolvedRRSets []powerdns.RRset) {
+	kept := make(map[common.RRsetKey]powerdns.RRset)
+	nameTypes := make(map[string][]powerdns.RRType)
+	var order []common.RRsetKey
+
+	for _, rrSet := range rrSets {
+		key := common.GetRRsetKey(rrSet)
+
+		// Everything already kept at this name that can't live alongside this RRset.
+		var conflicting []powerdns.RRset
+		for _, existingType := range nameTypes[key.Name] {
+			existingRRSet := kept[common.RRsetKey{Name: key.Name, Type: existingType}]
+			if existingType == key.Type {
+				if common.RRsetsEqual(existingRRSet, rrSet) {
+					conflicting = nil
+					break
+				}
+				conflicting = append(conflicting, existingRRSet)
+			} else if existingType == powerdns.RRTypeCNAME || key.Type == powerdns.RRTypeCNAME {
+				conflicting = append(conflicting, existingRRSet)
+			}
+		}
+		if _, found := kept[key]; found && len(conflicting) == 0 {
+			// An exact duplicate.
+			continue
+		}
+
+		// This RRset has to beat all of them to be kept.
+		wins := true
+		for _, existingRRSet := range conflicting {
+			if getConflictRank(existingRRSet) > getConflictRank(rrSet) {
+				plan.addConflict(newRecordConflict(existingRRSet, rrSet))
+				wins = false
+				break
+			}
+		}
+		if !wins {
+			continue
+		}
+
+		for _, existingRRSet := range conflicting {
+			plan.addConflict(newRecordConflict(rrSet, existingRRSet))
+
+			existingKey := common.GetRRsetKey(existingRRSet)
+			delete(kept, existingKey)
+			var types []powerdns.RRType
+			for _, existingType := range nameTypes[key.Name] {
+				if existingType != existingKey.Type {
+					types = append(types, existingType)
+				}
+			}
+			nameTypes[key.Name] = types
+		}
+
+		if _, found := kept[key]; !found {
+			nameTypes[key.Name] = append(nameTypes[key.Name], key.Type)
+			order = append(order, key)
+		}
+		kept[key] = rrSet
+	}
+
+	// Keep the order the RRsets were built in, a key can be in the order more than once if it was dropped and came back.
+	seen := make(map[common.RRsetKey]bool)
+	for _, key := range order {
+		rrSet, found := kept[key]
+		if !found || seen[key] {
+			continue
+		}
+		seen[key] = true
+		resolvedRRSets = append(resolvedRRSets, rrSet)
+	}
+
+	return
+}
+
+// recordConflicts makes the conflicts found by a full true up run available to the API and metrics. Dry runs aren't
+// recorded so a plan request can't replace what the manager is actually doing.
+func recordConflicts(plan *Plan) {
+	conflictCounts := make(map[ConflictReason]int)
+	for _, conflict := range plan.Conflicts {
+		conflictCounts[conflict.Reason]++
+	}
+
+	managerMetrics.RecordConflicts.Reset()
+	for _, reason := range []ConflictReason{ConflictReasonOverride, ConflictReasonCNAME, ConflictReasonRecords,
+		ConflictReasonPTR} {
+		managerMetrics.RecordConflicts.WithLabelValues(string(reason)).Set(float64(conflictCounts[reason]))
+	}
+
+	lastConflictsMtx.Lock()
+	defer lastConflictsMtx.Unlock()
+
+	lastConflicts = append([]RecordConflict{}, plan.Conflicts...)
+}
+
+// getLastConflicts returns a copy of the conflicts found by the last full true up run that wasn't a dry run.
+func getLastConflicts() []RecordConflict {
+	lastConflictsMtx.Lock()
+	defer lastConflictsMtx.Unlock()
+
+	return append([]RecordConflict{}, lastConflicts...)
+}
+
+// resolveComponentConflicts resolves conflicts for a component run. Only the HSM RRsets for the components are built so
+// they are checked against the SLS RRsets from the desired state of the last full run as well, otherwise they could
+// replace an SLS RRset that has precedence over them. Without a cached desired state the SLS RRsets are taken from the
+// zones instead, going by their ownership comments.
+func resolveComponentConflicts(rrSets []powerdns.RRset, zones []*powerdns.Zone,
+	plan *Plan) (resolvedRRSets []powerdns.RRset) {
+	var otherRRSets []powerdns.RRset
+	if stateCache.valid {
+		for _, cachedRRSet := range stateCache.desired {
+			if ownership, _ := common.GetRRsetOwnership(cachedRRSet); ownership.Source == common.OwnerSourceSLS {
+				otherRRSets = append(otherRRSets, cachedRRSet)
+			}
+		}
+	} else {
+		for _, zone := range zones {
+			for _, zoneRRSet := range zone.RRsets {
+				if ownership, _ := common.GetRRsetOwnership(zoneRRSet); ownership.Source == common.OwnerSourceSLS {
+					otherRRSets = append(otherRRSets, zoneRRSet)
+				}
+			}
+		}
+	}
+
+	// The other RRsets are already in PowerDNS, only the component RRsets that survive are wanted.
+	for _, rrSet := range resolveConflicts(append(otherRRSets, rrSets...), plan) {
+		if ownership, _ := common.GetRRsetOwnership(rrSet); ownership.Source == common.OwnerSourceHSM {
+			resolvedRRSets = append(resolvedRRSets, rrSet)
+		}
+	}
+
+	return
+}
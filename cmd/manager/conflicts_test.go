@@ -0,0 +1,152 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Cray-HPE/cray-powerdns-manager/internal/common"
+	"github.com/joeig/go-powerdns/v2"
+)
+
+func TestResolveConflicts(t *testing.T) {
+	sls := common.GetSLSHardwareOwnership("x3000c0s2b0n0")
+	hsm := common.GetHSMEthernetInterfaceOwnership("a4bf0138ee65")
+
+	tests := []struct {
+		name          string
+		precedence    string
+		rrSets        []powerdns.RRset
+		want          map[string][]string
+		wantConflicts []ConflictReason
+	}{
+		{
+			name:       "exact duplicates aren't conflicts",
+			precedence: ConflictPrecedenceHSM,
+			rrSets: []powerdns.RRset{
+				newTestRRset("node.nmn.example.com.", powerdns.RRTypeA, sls, "10.252.0.20"),
+				newTestRRset("node.nmn.example.com.", powerdns.RRTypeA, hsm, "10.252.0.20"),
+			},
+			want: map[string][]string{"node.nmn.example.com. A": {"10.252.0.20"}},
+		},
+		{
+			name:       "A and AAAA live together",
+			precedence: ConflictPrecedenceHSM,
+			rrSets: []powerdns.RRset{
+				newTestRRset("node.nmn.example.com.", powerdns.RRTypeA, sls, "10.252.0.20"),
+				newTestRRset("node.nmn.example.com.", powerdns.RRTypeAAAA, hsm, "fd00::20"),
+			},
+			want: map[string][]string{
+				"node.nmn.example.com. A":    {"10.252.0.20"},
+				"node.nmn.example.com. AAAA": {"fd00::20"},
+			},
+		},
+		{
+			name:       "SLS precedence keeps the SLS address",
+			precedence: ConflictPrecedenceSLS,
+			rrSets: []powerdns.RRset{
+				newTestRRset("node.nmn.example.com.", powerdns.RRTypeA, sls, "10.252.0.20"),
+				newTestRRset("node.nmn.example.com.", powerdns.RRTypeA, hsm, "10.252.0.21"),
+			},
+			want:          map[string][]string{"node.nmn.example.com. A": {"10.252.0.20"}},
+			wantConflicts: []ConflictReason{ConflictReasonRecords},
+		},
+		{
+			name:       "HSM precedence keeps the HSM address",
+			precedence: ConflictPrecedenceHSM,
+			rrSets: []powerdns.RRset{
+				newTestRRset("node.nmn.example.com.", powerdns.RRTypeA, sls, "10.252.0.20"),
+				newTestRRset("node.nmn.example.com.", powerdns.RRTypeA, hsm, "10.252.0.21"),
+			},
+			want:          map[string][]string{"node.nmn.example.com. A": {"10.252.0.21"}},
+			wantConflicts: []ConflictReason{ConflictReasonRecords},
+		},
+		{
+			name:       "CNAME can't share a name",
+			precedence: ConflictPrecedenceSLS,
+			rrSets: []powerdns.RRset{
+				newTestRRset("alias.nmn.example.com.", powerdns.RRTypeCNAME, sls, "node.nmn.example.com."),
+				newTestRRset("alias.nmn.example.com.", powerdns.RRTypeA, hsm, "10.252.0.21"),
+			},
+			want:          map[string][]string{"alias.nmn.example.com. CNAME": {"node.nmn.example.com."}},
+			wantConflicts: []ConflictReason{ConflictReasonCNAME},
+		},
+		{
+			name:       "CNAME replaces every other type",
+			precedence: ConflictPrecedenceHSM,
+			rrSets: []powerdns.RRset{
+				newTestRRset("alias.nmn.example.com.", powerdns.RRTypeA, sls, "10.252.0.20"),
+				newTestRRset("alias.nmn.example.com.", powerdns.RRTypeAAAA, sls, "fd00::20"),
+				newTestRRset("alias.nmn.example.com.", powerdns.RRTypeCNAME, hsm, "node.nmn.example.com."),
+			},
+			want:          map[string][]string{"alias.nmn.example.com. CNAME": {"node.nmn.example.com."}},
+			wantConflicts: []ConflictReason{ConflictReasonCNAME, ConflictReasonCNAME},
+		},
+		{
+			name:       "PTR claimed by two names",
+			precedence: ConflictPrecedenceSLS,
+			rrSets: []powerdns.RRset{
+				newTestRRset("20.0.252.10.in-addr.arpa.", powerdns.RRTypePTR, sls, "a.nmn.example.com."),
+				newTestRRset("20.0.252.10.in-addr.arpa.", powerdns.RRTypePTR, hsm, "b.nmn.example.com."),
+			},
+			want:          map[string][]string{"20.0.252.10.in-addr.arpa. PTR": {"a.nmn.example.com."}},
+			wantConflicts: []ConflictReason{ConflictReasonPTR},
+		},
+		{
+			name:       "later wins within a source",
+			precedence: ConflictPrecedenceSLS,
+			rrSets: []powerdns.RRset{
+				newTestRRset("node.nmn.example.com.", powerdns.RRTypeA, sls, "10.252.0.20"),
+				newTestRRset("node.nmn.example.com.", powerdns.RRTypeA, sls, "10.252.0.21"),
+			},
+			want:          map[string][]string{"node.nmn.example.com. A": {"10.252.0.21"}},
+			wantConflicts: []ConflictReason{ConflictReasonRecords},
+		},
+	}
+
+	defer func(precedence string) { *conflictPrecedence = precedence }(*conflictPrecedence)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			*conflictPrecedence = test.precedence
+			plan := NewPlan(true)
+
+			got := getTestRRsetContents(resolveConflicts(test.rrSets, plan))
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("resolveConflicts() = %v, want %v", got, test.want)
+			}
+
+			var gotConflicts []ConflictReason
+			for _, conflict := range plan.Conflicts {
+				gotConflicts = append(gotConflicts, conflict.Reason)
+			}
+			if !reflect.DeepEqual(gotConflicts, test.wantConflicts) {
+				t.Errorf("resolveConflicts() conflicts = %v, want %v", gotConflicts, test.wantConflicts)
+			}
+		})
+	}
+}
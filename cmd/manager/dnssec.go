@@ -0,0 +1,643 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/joeig/go-powerdns/v2"
+	"go.uber.org/zap"
+)
+
+const (
+	kskKeyType = "ksk"
+	zskKeyType = "zsk"
+	cskKeyType = "csk"
+
+	// PowerDNS doesn't record when a key was created so the manager keeps track of that itself in the zone metadata.
+	// Custom metadata kinds have to start with X-.
+	dnssecKeyStateMetadataKind = "X-CRAY-POWERDNS-MANAGER-KEYS"
+)
+
+var (
+	// dnssecNextSteps is when the next key rollover step is due for each zone, until then the zone's keys aren't looked
+	// at. Only runs that aren't dry runs fill it in.
+	dnssecNextSteps    = make(map[string]time.Time)
+	dnssecNextStepsMtx sync.Mutex
+)
+
+// managedKey is a cryptokey along with what the manager remembers about it.
+type managedKey struct {
+	powerdns.Cryptokey
+
+	Created time.Time
+	Retired time.Time
+}
+
+// zoneMetadata is the PowerDNS representation of a single kind of zone metadata.
+type zoneMetadata struct {
+	Kind     string   `json:"kind"`
+	Metadata []string `json:"metadata"`
+}
+
+// DSRecords are the DS records for a single KSK (or CSK) that need to be handed to the operator of the parent zone.
+type DSRecords struct {
+	Zone    string   `json:"zone"`
+	KeyID   uint64   `json:"key_id"`
+	KeyType string   `json:"keytype"`
+	Active  bool     `json:"active"`
+	DNSKey  string   `json:"dnskey"`
+	DS      []string `json:"ds"`
+}
+
+// getKeyStates returns the created and retired times of every key in the zone the manager knows about. Each metadata
+// entry is "<id> <created> <retired>" with the times in seconds since the epoch and a retired time of 0 if the key is
+// still in use.
+func getKeyStates(zoneName string) (states map[uint64]managedKey, err error) {
+	states = make(map[uint64]managedKey)
+
+	var metadata zoneMetadata
+	err = doPowerDNSRequest(http.MethodGet, fmt.Sprintf("zones/%s/metadata/%s", zoneName,
+		dnssecKeyStateMetadataKind), nil, &metadata)
+	if err != nil {
+		pdnsErr, ok := err.(*powerdns.Error)
+		if ok && pdnsErr.StatusCode == http.StatusNotFound {
+			err = nil
+		}
+		return
+	}
+
+	for _, entry := range metadata.Metadata {
+		fields := strings.Fields(entry)
+		if len(fields) != 3 {
+			logger.Warn("Ignoring malformed DNSSEC key state", zap.String("zoneName", zoneName),
+				zap.String("entry", entry))
+			continue
+		}
+
+		id, idErr := strconv.ParseUint(fields[0], 10, 64)
+		created, createdErr := strconv.ParseInt(fields[1], 10, 64)
+		retired, retiredErr := strconv.ParseInt(fields[2], 10, 64)
+		if idErr != nil || createdErr != nil || retiredErr != nil {
+			logger.Warn("Ignoring malformed DNSSEC key state", zap.String("zoneName", zoneName),
+				zap.String("entry", entry))
+			continue
+		}
+
+		state := managedKey{Created: time.Unix(created, 0)}
+		if retired != 0 {
+			state.Retired = time.Unix(retired, 0)
+		}
+		states[id] = state
+	}
+
+	return
+}
+
+// setKeyStates saves the created and retired times of the given keys to the zone metadata.
+func setKeyStates(zoneName string, keys []managedKey) error {
+	metadata := zoneMetadata{
+		Kind:     dnssecKeyStateMetadataKind,
+		Metadata: []string{},
+	}
+	for _, key := range keys {
+		var retired int64
+		if !key.Retired.IsZero() {
+			retired = key.Retired.Unix()
+		}
+		metadata.Metadata = append(metadata.Metadata, fmt.Sprintf("%d %d %d", *key.ID, key.Created.Unix(), retired))
+	}
+
+	err := doPowerDNSRequest(http.MethodPut, fmt.Sprintf("zones/%s/metadata/%s", zoneName,
+		dnssecKeyStateMetadataKind), metadata, nil)
+	if err != nil {
+		return fmt.Errorf("failed to save DNSSEC key state: %w", err)
+	}
+
+	return nil
+}
+
+// setCryptokeyActive activates or deactivates a key, the client library has no way of doing this.
+func setCryptokeyActive(zoneName string, id uint64, active bool) error {
+	err := doPowerDNSRequest(http.MethodPut, fmt.Sprintf("zones/%s/cryptokeys/%d", zoneName, id),
+		powerdns.Cryptokey{Active: powerdns.Bool(active)}, nil)
+	if err != nil {
+		return fmt.Errorf("failed to set cryptokey %d active to %t: %w", id, active, err)
+	}
+
+	return nil
+}
+
+// addManagedCryptokey generates a new key of the given type in the zone.
+func addManagedCryptokey(zoneName string, keyType string, active bool, now time.Time) (key managedKey, err error) {
+	cryptokey := &powerdns.Cryptokey{
+		KeyType:   powerdns.String(keyType),
+		Active:    powerdns.Bool(active),
+		Algorithm: dnssecAlgorithm,
+	}
+
+	cryptokey, err = pdns.Cryptokeys.Add(zoneName, cryptokey)
+	recordPowerDNSResult(err)
+	if err != nil {
+		err = fmt.Errorf("failed to add %s: %w", keyType, err)
+		return
+	}
+	if cryptokey.ID == nil {
+		err = fmt.Errorf("PowerDNS did not return the ID of the new %s", keyType)
+		return
+	}
+
+	key = managedKey{
+		Cryptokey: *cryptokey,
+		Created:   now,
+	}
+
+	return
+}
+
+// isManagedDNSSECZone returns true if the manager should look after the keys for the zone. Zones with a custom key from
+// the key directory are signed with that and left alone.
+func isManagedDNSSECZone(zoneName string) bool {
+	customDNSSECKey, _ := getZoneKeys(zoneName)
+	return customDNSSECKey == nil
+}
+
+// isDNSSECStepDue returns true if the keys of the zone need looking at. Zones PowerDNS says aren't signed always do,
+// that covers zones that were just created or had their keys removed from under the manager.
+func isDNSSECStepDue(zone *powerdns.Zone, now time.Time) bool {
+	if zone.DNSsec == nil || !*zone.DNSsec {
+		return true
+	}
+
+	dnssecNextStepsMtx.Lock()
+	defer dnssecNextStepsMtx.Unlock()
+
+	nextStep, found := dnssecNextSteps[*zone.Name]
+	return !found || !now.Before(nextStep)
+}
+
+// setDNSSECNextStep records when the keys of the zone next need looking at.
+func setDNSSECNextStep(zoneName string, nextStep time.Time) {
+	dnssecNextStepsMtx.Lock()
+	defer dnssecNextStepsMtx.Unlock()
+
+	dnssecNextSteps[zoneName] = nextStep
+}
+
+// getDNSSECNextStep returns when the next rollover step is due for the given keys. With nothing scheduled the keys are
+// still checked once every propagation delay in case they were changed outside of the manager.
+func getDNSSECNextStep(keys []managedKey, now time.Time) time.Time {
+	propagationDelay := time.Duration(*dnssecPropagationDelay) * time.Second
+	doubleSignaturePeriod := time.Duration(*dnssecKSKDoubleSignaturePeriod) * time.Second
+
+	nextStep := now.Add(propagationDelay)
+	schedule := func(step time.Time) {
+		if step.Before(nextStep) {
+			nextStep = step
+		}
+	}
+
+	var newestKSK, newestZSK *managedKey
+	activeKSKs, publishedZSKs := 0, 0
+	for i := range keys {
+		key := &keys[i]
+		active := key.Active != nil && *key.Active
+
+		switch {
+		case !key.Retired.IsZero():
+			schedule(key.Retired.Add(propagationDelay))
+		case *key.KeyType == kskKeyType && active:
+			activeKSKs++
+			if newestKSK == nil || key.Created.After(newestKSK.Created) {
+				newestKSK = key
+			}
+		case *key.KeyType == kskKeyType:
+			schedule(key.Created.Add(doubleSignaturePeriod))
+		case *key.KeyType == zskKeyType && active:
+			if newestZSK == nil || key.Created.After(newestZSK.Created) {
+				newestZSK = key
+			}
+		case *key.KeyType == zskKeyType:
+			publishedZSKs++
+			schedule(key.Created.Add(propagationDelay))
+		}
+	}
+
+	switch {
+	case newestKSK == nil:
+		return now
+	case activeKSKs > 1:
+		schedule(newestKSK.Created.Add(doubleSignaturePeriod))
+	case *dnssecKSKRolloverInterval > 0:
+		schedule(newestKSK.Created.Add(time.Duration(*dnssecKSKRolloverInterval) * time.Second))
+	}
+
+	switch {
+	case newestZSK == nil:
+		return now
+	case publishedZSKs == 0 && *dnssecZSKRolloverInterval > 0:
+		schedule(newestZSK.Created.Add(time.Duration(*dnssecZSKRolloverInterval) * time.Second))
+	}
+
+	return nextStep
+}
+
+// trueUpZoneDNSSEC makes sure the zone is signed with a KSK and ZSK and rolls them over when they get too old.
+//
+// ZSKs are rolled with pre-publication: the new key is published inactive, once it has been visible for the propagation
+// delay it takes over signing, and the old key is removed after another propagation delay. KSKs are rolled with double
+// signatures: the new key is added active alongside the old one, the DS records have the double signature period to
+// be updated at the parent, then the old key is deactivated and finally removed after a propagation delay. Inactive
+// keys that aren't part of a rollover, such as ones adopted from PowerDNS, are retired once they're past the window
+// they would have had in one.
+//
+// The keys are only listed when the next step is due, see isDNSSECStepDue, dry runs always list them.
+func trueUpZoneDNSSEC(zone *powerdns.Zone, plan *Plan, dryRun bool, now time.Time) (err error) {
+	zoneName := *zone.Name
+	zonePlan := plan.getZonePlan(zoneName)
+	zoneLogger := logger.With(zap.String("zoneName", zoneName))
+
+	// Every change is recorded in the plan and, unless this is a dry run, made.
+	change := func(description string, doChange func() error) bool {
+		zonePlan.DNSSECChanges = append(zonePlan.DNSSECChanges, description)
+		if dryRun {
+			zoneLogger.Info("DNSSEC change would be made", zap.String("change", description))
+			return true
+		}
+
+		changeErr := doChange()
+		if changeErr != nil {
+			err = fmt.Errorf("failed to %s: %w", description, changeErr)
+			return false
+		}
+
+		zoneLogger.Info("Made DNSSEC change", zap.String("change", description))
+		return true
+	}
+
+	if *nsec3Param != "" && (zone.Nsec3Param == nil || *zone.Nsec3Param != *nsec3Param) {
+		if !change(fmt.Sprintf("set NSEC3PARAM to %s", *nsec3Param), func() error {
+			changeErr := pdns.Zones.Change(zoneName, &powerdns.Zone{Nsec3Param: nsec3Param})
+			recordPowerDNSResult(changeErr)
+			return changeErr
+		}) {
+			return
+		}
+		if !dryRun {
+			zone.Nsec3Param = nsec3Param
+		}
+	}
+
+	if !dryRun && !isDNSSECStepDue(zone, now) {
+		zoneLogger.Debug("No DNSSEC key rollover step is due.")
+		return
+	}
+
+	cryptokeys, err := pdns.Cryptokeys.List(zoneName)
+	recordPowerDNSResult(err)
+	if err != nil {
+		err = fmt.Errorf("failed to list cryptokeys: %w", err)
+		return
+	}
+	states, err := getKeyStates(zoneName)
+	if err != nil {
+		err = fmt.Errorf("failed to get DNSSEC key state: %w", err)
+		return
+	}
+
+	var keys []managedKey
+	statesChanged := false
+	for _, cryptokey := range cryptokeys {
+		if cryptokey.ID == nil || cryptokey.KeyType == nil {
+			continue
+		}
+
+		if *cryptokey.KeyType == cskKeyType {
+			zoneLogger.Debug("Zone is signed with a CSK, not managing its DNSSEC keys.")
+			if !dryRun {
+				setDNSSECNextStep(zoneName, now.Add(time.Duration(*dnssecPropagationDelay)*time.Second))
+			}
+			return
+		}
+
+		// Keys the manager doesn't know about yet are adopted as if they were created now.
+		state, found := states[*cryptokey.ID]
+		if !found {
+			state.Created = now
+			statesChanged = true
+		}
+		state.Cryptokey = cryptokey
+		keys = append(keys, state)
+	}
+	if len(states) != len(keys) {
+		statesChanged = true
+	}
+
+	// Once everything is done work out when to look again, anything that failed is looked at again next run.
+	defer func() {
+		if dryRun || err != nil {
+			return
+		}
+
+		// The zone may be the one cached by incremental runs which is never fetched again, it needs to show the keys.
+		zone.DNSsec = powerdns.Bool(len(keys) > 0)
+		setDNSSECNextStep(zoneName, getDNSSECNextStep(keys, now))
+	}()
+
+	// Always save what happened even if something failed part way.
+	defer func() {
+		if dryRun || !statesChanged {
+			return
+		}
+
+		stateErr := setKeyStates(zoneName, keys)
+		if stateErr != nil && err == nil {
+			err = stateErr
+		}
+	}()
+
+	keysChanged := false
+	defer func() {
+		if dryRun || !keysChanged {
+			return
+		}
+
+		_, rectifyErr := pdns.Zones.Rectify(zoneName)
+		if rectifyErr != nil {
+			zoneLogger.Error("Failed to rectify zone", zap.Error(rectifyErr))
+		}
+	}()
+
+	addKey := func(keyType string, active bool) bool {
+		description := fmt.Sprintf("add %s", keyType)
+		if !active {
+			description = fmt.Sprintf("pre-publish %s", keyType)
+		}
+
+		return change(description, func() error {
+			key, addErr := addManagedCryptokey(zoneName, keyType, active, now)
+			if addErr != nil {
+				return addErr
+			}
+
+			keys = append(keys, key)
+			statesChanged = true
+			keysChanged = true
+
+			if keyType == kskKeyType {
+				zoneLogger.Warn("Added KSK, the DS records at the parent zone need updating",
+					zap.Strings("ds", key.DS))
+			}
+			return nil
+		})
+	}
+
+	// Keys that were retired long enough ago are removed for good.
+	var remainingKeys []managedKey
+	for _, key := range keys {
+		if !key.Retired.IsZero() && now.Sub(key.Retired) >= time.Duration(*dnssecPropagationDelay)*time.Second {
+			if !change(fmt.Sprintf("delete %s %d", *key.KeyType, *key.ID), func() error {
+				deleteErr := pdns.Cryptokeys.Delete(zoneName, *key.ID)
+				recordPowerDNSResult(deleteErr)
+				return deleteErr
+			}) {
+				return
+			}
+
+			statesChanged = true
+			keysChanged = true
+			continue
+		}
+
+		remainingKeys = append(remainingKeys, key)
+	}
+	keys = remainingKeys
+
+	// Sort out which keys are doing what, newest first.
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].Created.After(keys[j].Created)
+	})
+	var activeKSKs, activeZSKs, publishedZSKs, inactiveKSKs []int
+	for i, key := range keys {
+		if !key.Retired.IsZero() {
+			continue
+		}
+
+		active := key.Active != nil && *key.Active
+		switch {
+		case *key.KeyType == kskKeyType && active:
+			activeKSKs = append(activeKSKs, i)
+		case *key.KeyType == zskKeyType && active:
+			activeZSKs = append(activeZSKs, i)
+		case *key.KeyType == kskKeyType && !active:
+			inactiveKSKs = append(inactiveKSKs, i)
+		case *key.KeyType == zskKeyType && !active:
+			publishedZSKs = append(publishedZSKs, i)
+		}
+	}
+
+	// KSKs are never pre-published and only the newest ZSK takes over, so any other inactive key is left over. Once it's
+	// past the double signature period or propagation delay it's retired and removed like any other.
+	staleKeys := append([]int{}, inactiveKSKs...)
+	if len(publishedZSKs) > 1 {
+		staleKeys = append(staleKeys, publishedZSKs[1:]...)
+		publishedZSKs = publishedZSKs[:1]
+	}
+	for _, i := range staleKeys {
+		key := &keys[i]
+		window := time.Duration(*dnssecPropagationDelay) * time.Second
+		if *key.KeyType == kskKeyType {
+			window = time.Duration(*dnssecKSKDoubleSignaturePeriod) * time.Second
+		}
+		if now.Sub(key.Created) < window {
+			continue
+		}
+
+		if !change(fmt.Sprintf("retire inactive %s %d", *key.KeyType, *key.ID), func() error {
+			key.Retired = now
+			statesChanged = true
+			return nil
+		}) {
+			return
+		}
+	}
+
+	retire := func(i int) bool {
+		key := &keys[i]
+		return change(fmt.Sprintf("deactivate %s %d", *key.KeyType, *key.ID), func() error {
+			activeErr := setCryptokeyActive(zoneName, *key.ID, false)
+			if activeErr != nil {
+				return activeErr
+			}
+
+			key.Active = powerdns.Bool(false)
+			key.Retired = now
+			statesChanged = true
+			keysChanged = true
+			return nil
+		})
+	}
+
+	// KSK.
+	switch {
+	case len(activeKSKs) == 0:
+		if !addKey(kskKeyType, true) {
+			return
+		}
+	case len(activeKSKs) > 1:
+		// A rollover is in progress, once the double signature period is over the old keys go.
+		newest := keys[activeKSKs[0]]
+		if now.Sub(newest.Created) >= time.Duration(*dnssecKSKDoubleSignaturePeriod)*time.Second {
+			for _, i := range activeKSKs[1:] {
+				if !retire(i) {
+					return
+				}
+			}
+		}
+	case *dnssecKSKRolloverInterval > 0 &&
+		now.Sub(keys[activeKSKs[0]].Created) >= time.Duration(*dnssecKSKRolloverInterval)*time.Second:
+		if !addKey(kskKeyType, true) {
+			return
+		}
+	}
+
+	// ZSK.
+	switch {
+	case len(publishedZSKs) > 0:
+		// A rollover is in progress, once the new key has been published long enough it takes over.
+		successor := &keys[publishedZSKs[0]]
+		if now.Sub(successor.Created) >= time.Duration(*dnssecPropagationDelay)*time.Second {
+			if !change(fmt.Sprintf("activate %s %d", *successor.KeyType, *successor.ID), func() error {
+				activeErr := setCryptokeyActive(zoneName, *successor.ID, true)
+				if activeErr != nil {
+					return activeErr
+				}
+
+				successor.Active = powerdns.Bool(true)
+				keysChanged = true
+				return nil
+			}) {
+				return
+			}
+
+			for _, i := range activeZSKs {
+				if !retire(i) {
+					return
+				}
+			}
+		}
+	case len(activeZSKs) == 0:
+		if !addKey(zskKeyType, true) {
+			return
+		}
+	case *dnssecZSKRolloverInterval > 0 &&
+		now.Sub(keys[activeZSKs[0]].Created) >= time.Duration(*dnssecZSKRolloverInterval)*time.Second:
+		if !addKey(zskKeyType, false) {
+			return
+		}
+	}
+
+	return
+}
+
+// trueUpDNSSEC manages the DNSSEC keys of every zone. Zones that only exist in the plan because this is a dry run are
+// skipped as there is nothing to look at yet.
+func trueUpDNSSEC(zones []*powerdns.Zone, plan *Plan, dryRun bool) {
+	if !*dnssecEnabled {
+		return
+	}
+
+	now := time.Now()
+	for _, zone := range zones {
+		if zone == nil || zone.Name == nil || !isManagedDNSSECZone(*zone.Name) {
+			continue
+		}
+
+		zonePlan := plan.getZonePlan(*zone.Name)
+		if dryRun && zonePlan.CreateZone {
+			continue
+		}
+
+		err := trueUpZoneDNSSEC(zone, plan, dryRun, now)
+		if err != nil {
+			logger.Error("Failed to true up DNSSEC for zone!", zap.String("zoneName", *zone.Name), zap.Error(err))
+			zonePlan.Error = fmt.Sprintf("failed to true up DNSSEC: %s", err)
+		}
+	}
+}
+
+// getDSRecords returns the DS records for every KSK or CSK in the given zones.
+func getDSRecords(zoneNames []string) (dsRecords []DSRecords, err error) {
+	dsRecords = []DSRecords{}
+
+	for _, zoneName := range zoneNames {
+		var cryptokeys []powerdns.Cryptokey
+		cryptokeys, err = pdns.Cryptokeys.List(zoneName)
+		recordPowerDNSResult(err)
+		if err != nil {
+			err = fmt.Errorf("failed to list cryptokeys for %s: %w", zoneName, err)
+			return
+		}
+
+		for _, cryptokey := range cryptokeys {
+			if cryptokey.ID == nil || cryptokey.KeyType == nil || *cryptokey.KeyType == zskKeyType {
+				continue
+			}
+
+			// The list doesn't always include the DS records, the key itself does.
+			if len(cryptokey.DS) == 0 {
+				var fullCryptokey *powerdns.Cryptokey
+				fullCryptokey, err = pdns.Cryptokeys.Get(zoneName, *cryptokey.ID)
+				recordPowerDNSResult(err)
+				if err != nil {
+					err = fmt.Errorf("failed to get cryptokey %d for %s: %w", *cryptokey.ID, zoneName, err)
+					return
+				}
+				cryptokey = *fullCryptokey
+			}
+
+			dsRecord := DSRecords{
+				Zone:    zoneName,
+				KeyID:   *cryptokey.ID,
+				KeyType: *cryptokey.KeyType,
+				Active:  cryptokey.Active != nil && *cryptokey.Active,
+				DS:      cryptokey.DS,
+			}
+			if cryptokey.DNSkey != nil {
+				dsRecord.DNSKey = *cryptokey.DNSkey
+			}
+			dsRecords = append(dsRecords, dsRecord)
+		}
+	}
+
+	return
+}
@@ -0,0 +1,124 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/joeig/go-powerdns/v2"
+)
+
+func TestGetDNSSECNextStep(t *testing.T) {
+	now := time.Unix(1800000000, 0)
+	day := 24 * time.Hour
+
+	newKey := func(keyType string, active bool, created time.Duration, retired time.Duration) managedKey {
+		key := managedKey{
+			Cryptokey: powerdns.Cryptokey{
+				ID:      powerdns.Uint64(1),
+				KeyType: powerdns.String(keyType),
+				Active:  powerdns.Bool(active),
+			},
+			Created: now.Add(-created),
+		}
+		if retired != 0 {
+			key.Retired = now.Add(-retired)
+		}
+		return key
+	}
+
+	tests := []struct {
+		name string
+		keys []managedKey
+		want time.Time
+	}{
+		{
+			name: "no keys",
+			want: now,
+		},
+		{
+			name: "ZSK rollover",
+			keys: []managedKey{
+				newKey(kskKeyType, true, 100*day, 0),
+				newKey(zskKeyType, true, 29*day+12*time.Hour, 0),
+			},
+			want: now.Add(12 * time.Hour),
+		},
+		{
+			name: "nothing due is checked after a propagation delay",
+			keys: []managedKey{
+				newKey(kskKeyType, true, 100*day, 0),
+				newKey(zskKeyType, true, 10*day, 0),
+			},
+			want: now.Add(day),
+		},
+		{
+			name: "pre-published ZSK",
+			keys: []managedKey{
+				newKey(kskKeyType, true, 100*day, 0),
+				newKey(zskKeyType, true, 40*day, 0),
+				newKey(zskKeyType, false, 6*time.Hour, 0),
+			},
+			want: now.Add(18 * time.Hour),
+		},
+		{
+			name: "KSK double signatures",
+			keys: []managedKey{
+				newKey(kskKeyType, true, 100*day, 0),
+				newKey(kskKeyType, true, 6*day+20*time.Hour, 0),
+				newKey(zskKeyType, true, 10*day, 0),
+			},
+			want: now.Add(4 * time.Hour),
+		},
+		{
+			name: "retired key removal",
+			keys: []managedKey{
+				newKey(kskKeyType, true, 100*day, 0),
+				newKey(zskKeyType, true, 10*day, 0),
+				newKey(zskKeyType, false, 40*day, 22*time.Hour),
+			},
+			want: now.Add(2 * time.Hour),
+		},
+		{
+			name: "inactive KSK",
+			keys: []managedKey{
+				newKey(kskKeyType, true, 100*day, 0),
+				newKey(kskKeyType, false, 6*day+21*time.Hour, 0),
+				newKey(zskKeyType, true, 10*day, 0),
+			},
+			want: now.Add(3 * time.Hour),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := getDNSSECNextStep(test.keys, now); !got.Equal(test.want) {
+				t.Errorf("getDNSSECNextStep() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
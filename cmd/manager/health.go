@@ -0,0 +1,133 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package main
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/joeig/go-powerdns/v2"
+)
+
+const (
+	DependencyPowerDNS = "powerdns"
+	DependencySLS      = "sls"
+	DependencyHSM      = "hsm"
+)
+
+// DependencyHealth is what the manager has seen of a single upstream service.
+type DependencyHealth struct {
+	LastSuccess  *time.Time `json:"last_success,omitempty"`
+	LastError    string     `json:"last_error,omitempty"`
+	FailingSince *time.Time `json:"failing_since,omitempty"`
+}
+
+// ReadinessStatus is returned by the readiness endpoint when the manager is not ready.
+type ReadinessStatus struct {
+	Ready              bool                        `json:"ready"`
+	TrueUpSucceeded    bool                        `json:"true_up_succeeded"`
+	FailedDependencies []string                    `json:"failed_dependencies,omitempty"`
+	Dependencies       map[string]DependencyHealth `json:"dependencies"`
+}
+
+var (
+	dependencyHealth = map[string]*DependencyHealth{
+		DependencyPowerDNS: {},
+		DependencySLS:      {},
+		DependencyHSM:      {},
+	}
+	trueUpSucceeded bool
+	healthMtx       sync.Mutex
+)
+
+// recordDependencyResult updates the health of the given dependency from the outcome of a request to it.
+func recordDependencyResult(dependency string, err error) {
+	healthMtx.Lock()
+	defer healthMtx.Unlock()
+
+	health, found := dependencyHealth[dependency]
+	if !found {
+		return
+	}
+
+	now := time.Now()
+	if err == nil {
+		health.LastSuccess = &now
+		health.LastError = ""
+		health.FailingSince = nil
+	} else {
+		health.LastError = err.Error()
+		if health.FailingSince == nil {
+			health.FailingSince = &now
+		}
+	}
+}
+
+// recordPowerDNSResult is the same as recordDependencyResult except that a client error from the PowerDNS API (e.g., a
+// 404 for a zone that doesn't exist yet) still means PowerDNS is reachable. Server errors don't, a PowerDNS that can't
+// reach its backend answers every request with a 500.
+func recordPowerDNSResult(err error) {
+	var pdnsErr *powerdns.Error
+	if errors.As(err, &pdnsErr) && pdnsErr.StatusCode >= 400 && pdnsErr.StatusCode < 500 {
+		err = nil
+	}
+
+	recordDependencyResult(DependencyPowerDNS, err)
+}
+
+// recordTrueUpSucceeded marks that at least one true up run has completed successfully since the manager started.
+func recordTrueUpSucceeded() {
+	healthMtx.Lock()
+	defer healthMtx.Unlock()
+
+	trueUpSucceeded = true
+}
+
+// getReadiness works out whether the manager is ready. It isn't until the first true up run succeeds and stops being
+// ready whenever any dependency has been failing for longer than the configured threshold.
+func getReadiness() (status ReadinessStatus) {
+	healthMtx.Lock()
+	defer healthMtx.Unlock()
+
+	threshold := time.Duration(*dependencyFailureThreshold) * time.Second
+
+	status.TrueUpSucceeded = trueUpSucceeded
+	status.Dependencies = make(map[string]DependencyHealth)
+	for dependency, health := range dependencyHealth {
+		status.Dependencies[dependency] = *health
+
+		if health.FailingSince != nil && time.Since(*health.FailingSince) >= threshold {
+			status.FailedDependencies = append(status.FailedDependencies, dependency)
+		}
+	}
+	sort.Strings(status.FailedDependencies)
+
+	status.Ready = status.TrueUpSucceeded && len(status.FailedDependencies) == 0
+
+	return
+}
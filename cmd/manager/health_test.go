@@ -0,0 +1,63 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/joeig/go-powerdns/v2"
+)
+
+func TestRecordPowerDNSResult(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		wantFailing bool
+	}{
+		{name: "success"},
+		{name: "not found", err: &powerdns.Error{StatusCode: http.StatusNotFound}},
+		{name: "wrapped unprocessable entity",
+			err: fmt.Errorf("failed to patch zone: %w", &powerdns.Error{StatusCode: http.StatusUnprocessableEntity})},
+		{name: "server error", err: &powerdns.Error{StatusCode: http.StatusInternalServerError}, wantFailing: true},
+		{name: "unreachable", err: errors.New("connection refused"), wantFailing: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dependencyHealth[DependencyPowerDNS] = &DependencyHealth{}
+
+			recordPowerDNSResult(test.err)
+
+			failing := dependencyHealth[DependencyPowerDNS].FailingSince != nil
+			if failing != test.wantFailing {
+				t.Errorf("recordPowerDNSResult(%v) failing = %t, want %t", test.err, failing, test.wantFailing)
+			}
+		})
+	}
+}
@@ -33,10 +33,29 @@ import (
 	"github.com/Cray-HPE/hms-smd/pkg/sm"
 	"github.com/hashicorp/go-retryablehttp"
 	"io/ioutil"
+	"net/http"
+	neturl "net/url"
+	"strings"
+	"time"
 )
 
-func getHSMEthernetInterfaces() (ethernetInterfaces []sm.CompEthInterfaceV2, err error) {
+// getHSMEthernetInterfaces returns the ethernet interfaces from HSM, only those belonging to the given components if
+// there are any.
+func getHSMEthernetInterfaces(componentIDs ...string) (ethernetInterfaces []sm.CompEthInterfaceV2, err error) {
+	start := time.Now()
+	defer func() {
+		managerMetrics.ObserveFetch("hsm", "ethernet_interfaces", start, err)
+		recordDependencyResult(DependencyHSM, err)
+	}()
+
 	url := fmt.Sprintf("%s/hsm/v2/Inventory/EthernetInterfaces", *hsmURL)
+	if len(componentIDs) > 0 {
+		query := neturl.Values{}
+		for _, componentID := range componentIDs {
+			query.Add("ComponentID", componentID)
+		}
+		url = fmt.Sprintf("%s?%s", url, query.Encode())
+	}
 	req, err := retryablehttp.NewRequest("GET", url, nil)
 	if err != nil {
 		err = fmt.Errorf("failed to create new request: %w", err)
@@ -65,6 +84,12 @@ func getHSMEthernetInterfaces() (ethernetInterfaces []sm.CompEthInterfaceV2, err
 
 // getHSMNodeState returns a ComponentArray for all SMD objects of type "Node".
 func getHSMNodeState() (stateComponents base.ComponentArray, err error) {
+	start := time.Now()
+	defer func() {
+		managerMetrics.ObserveFetch("hsm", "state_components", start, err)
+		recordDependencyResult(DependencyHSM, err)
+	}()
+
 	url := fmt.Sprintf("%s/hsm/v2/State/Components", *hsmURL)
 	reqBody := bytes.NewReader([]byte("{'Type': 'Node'}"))
 	req, err := retryablehttp.NewRequest("GET", url, reqBody)
@@ -93,3 +118,97 @@ func getHSMNodeState() (stateComponents base.ComponentArray, err error) {
 
 	return
 }
+
+// isSubscribedToHSMSCN returns true if HSM has an enabled state change notification subscription from the manager to
+// the callback URL.
+func isSubscribedToHSMSCN() (subscribed bool, err error) {
+	defer func() {
+		recordDependencyResult(DependencyHSM, err)
+	}()
+
+	url := fmt.Sprintf("%s/hsm/v2/Subscriptions/SCN", *hsmURL)
+	req, err := retryablehttp.NewRequest("GET", url, nil)
+	if err != nil {
+		err = fmt.Errorf("failed to create new request: %w", err)
+		return
+	}
+	if token != "" {
+		req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", token))
+	}
+	req = req.WithContext(ctx)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		err = fmt.Errorf("failed to do request: %w", err)
+		return
+	}
+	defer resp.Body.Close()
+
+	body, _ := ioutil.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		err = fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, string(body))
+		return
+	}
+
+	var subscriptions sm.SCNSubscriptionArray
+	err = json.Unmarshal(body, &subscriptions)
+	if err != nil {
+		err = fmt.Errorf("failed to unmarshal body: %w", err)
+		return
+	}
+
+	for _, subscription := range subscriptions.SubscriptionList {
+		if subscription.Subscriber == scnSubscriber && subscription.Url == *hsmSCNCallbackURL &&
+			(subscription.Enabled == nil || *subscription.Enabled) {
+			subscribed = true
+			return
+		}
+	}
+
+	return
+}
+
+// subscribeToHSMSCN asks HSM to send state change notifications for nodes to the callback URL. HSM refuses to create
+// the same subscription twice so a conflict means we're already subscribed.
+func subscribeToHSMSCN() (err error) {
+	enabled := true
+	subscription := sm.SCNPostSubscription{
+		Subscriber: scnSubscriber,
+		Enabled:    &enabled,
+		States:     strings.Split(*hsmSCNStates, ","),
+		Url:        *hsmSCNCallbackURL,
+	}
+
+	subscriptionBytes, err := json.Marshal(subscription)
+	if err != nil {
+		err = fmt.Errorf("failed to marshal subscription: %w", err)
+		return
+	}
+
+	url := fmt.Sprintf("%s/hsm/v2/Subscriptions/SCN", *hsmURL)
+	req, err := retryablehttp.NewRequest("POST", url, bytes.NewReader(subscriptionBytes))
+	if err != nil {
+		err = fmt.Errorf("failed to create new request: %w", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", token))
+	}
+	req = req.WithContext(ctx)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		err = fmt.Errorf("failed to do request: %w", err)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated &&
+		resp.StatusCode != http.StatusConflict {
+		body, _ := ioutil.ReadAll(resp.Body)
+		err = fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, string(body))
+	}
+
+	return
+}
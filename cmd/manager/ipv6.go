@@ -0,0 +1,185 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package main
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/Cray-HPE/cray-powerdns-manager/internal/common"
+	sls_common "github.com/Cray-HPE/hms-sls/pkg/sls-common"
+	"github.com/Cray-HPE/hms-xname/xnametypes"
+	"github.com/joeig/go-powerdns/v2"
+	"github.com/mitchellh/mapstructure"
+	"go.uber.org/zap"
+)
+
+// defaultIPv6PrefixLength is assumed for cabinet IPv6 prefixes given without a length.
+const defaultIPv6PrefixLength = 64
+
+// hardwareIPv6Properties are the only extra properties we care about for IPv6, several hardware types carry them.
+type hardwareIPv6Properties struct {
+	IP6Addr string `mapstructure:"IP6addr"`
+}
+
+// getIPv6Networks returns every IPv6 network known to SLS, those in the network IP ranges as well as the per cabinet
+// prefixes, along with the name of the network they belong to.
+func getIPv6Networks(networks []sls_common.Network,
+	hardware []sls_common.GenericHardware) (ipv6Networks []common.NetworkNameCIDRMap) {
+	addNetwork := func(name string, cidr *net.IPNet) {
+		for _, ipv6Network := range ipv6Networks {
+			if ipv6Network.Name == name && ipv6Network.CIDR.String() == cidr.String() {
+				return
+			}
+		}
+
+		ipv6Networks = append(ipv6Networks, common.NetworkNameCIDRMap{
+			Name: name,
+			CIDR: cidr,
+		})
+	}
+
+	for _, network := range networks {
+		for _, ipRange := range network.IPRanges {
+			_, cidr, err := net.ParseCIDR(ipRange)
+			if err != nil || cidr.IP.To4() != nil {
+				continue
+			}
+
+			addNetwork(strings.ToLower(network.Name), cidr)
+		}
+	}
+
+	for _, device := range hardware {
+		if device.TypeString != xnametypes.Cabinet {
+			continue
+		}
+
+		var cabinetProperties sls_common.ComptypeCabinet
+		err := mapstructure.Decode(device.ExtraPropertiesRaw, &cabinetProperties)
+		if err != nil {
+			logger.Error("Failed to decode cabinet extra properties!", zap.Error(err),
+				zap.String("xname", device.Xname))
+			continue
+		}
+
+		for _, hardwareNetworks := range cabinetProperties.Networks {
+			for networkName, cabinetNetwork := range hardwareNetworks {
+				if cabinetNetwork.IPv6Prefix == "" {
+					continue
+				}
+
+				prefix := cabinetNetwork.IPv6Prefix
+				if !strings.Contains(prefix, "/") {
+					prefix = fmt.Sprintf("%s/%d", prefix, defaultIPv6PrefixLength)
+				}
+
+				_, cidr, err := net.ParseCIDR(prefix)
+				if err != nil || cidr.IP.To4() != nil {
+					logger.Error("Failed to parse cabinet IPv6 prefix!", zap.Error(err),
+						zap.String("xname", device.Xname), zap.String("IPv6Prefix", cabinetNetwork.IPv6Prefix))
+					continue
+				}
+
+				addNetwork(strings.ToLower(networkName), cidr)
+			}
+		}
+	}
+
+	return
+}
+
+// getNetworkForIP returns the name of the network the IP belongs to, or a blank string if it doesn't belong to any.
+func getNetworkForIP(ip net.IP, networkNameCIDRMaps []common.NetworkNameCIDRMap) string {
+	for _, network := range networkNameCIDRMaps {
+		if network.CIDR != nil && network.CIDR.Contains(ip) {
+			return network.Name
+		}
+	}
+
+	return ""
+}
+
+// buildStaticIPv6RRSets builds AAAA and PTR RRsets for every SLS hardware object that has an IPv6 address.
+func buildStaticIPv6RRSets(hardware []sls_common.GenericHardware,
+	ipv6Networks []common.NetworkNameCIDRMap) (staticRRSets []powerdns.RRset, err error) {
+	for _, device := range hardware {
+		var properties hardwareIPv6Properties
+		err = mapstructure.Decode(device.ExtraPropertiesRaw, &properties)
+		if err != nil {
+			return
+		}
+		if properties.IP6Addr == "" {
+			continue
+		}
+
+		ip := net.ParseIP(properties.IP6Addr)
+		if ip == nil || ip.To4() != nil {
+			logger.Debug("Hardware IPv6 address is not valid!", zap.String("xname", device.Xname),
+				zap.String("IP6Addr", properties.IP6Addr))
+			continue
+		}
+
+		networkDomain := getNetworkForIP(ip, ipv6Networks)
+		if networkDomain == "" {
+			logger.Debug("Hardware IPv6 address does not belong to any SLS network",
+				zap.String("xname", device.Xname), zap.String("IP6Addr", properties.IP6Addr))
+			continue
+		}
+
+		ownership := common.GetSLSHardwareOwnership(device.Xname)
+		primaryName := fmt.Sprintf("%s.%s.%s.", device.Xname, networkDomain, *baseDomain)
+
+		staticRRSets = append(staticRRSets, powerdns.RRset{
+			Name:       powerdns.String(primaryName),
+			Type:       powerdns.RRTypePtr(powerdns.RRTypeAAAA),
+			TTL:        powerdns.Uint32(recordTTLs.Get(powerdns.RRTypeAAAA, networkDomain)),
+			ChangeType: powerdns.ChangeTypePtr(powerdns.ChangeTypeReplace),
+			Comments:   common.GetOwnershipComments(ownership),
+			Records: []powerdns.Record{
+				{
+					Content:  powerdns.String(ip.String()),
+					Disabled: powerdns.Bool(false),
+				},
+			},
+		}, powerdns.RRset{
+			Name:       powerdns.String(common.MakeDomainCanonical(common.GetReverseNameForIP(ip))),
+			Type:       powerdns.RRTypePtr(powerdns.RRTypePTR),
+			TTL:        powerdns.Uint32(recordTTLs.Get(powerdns.RRTypePTR, networkDomain)),
+			ChangeType: powerdns.ChangeTypePtr(powerdns.ChangeTypeReplace),
+			Comments:   common.GetOwnershipComments(ownership),
+			Records: []powerdns.Record{
+				{
+					Content:  powerdns.String(primaryName),
+					Disabled: powerdns.Bool(false),
+				},
+			},
+		})
+	}
+
+	return
+}
@@ -0,0 +1,221 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// JobTrigger is what caused a true up run.
+type JobTrigger string
+
+const (
+	JobTriggerStartup JobTrigger = "startup"
+	JobTriggerTimer   JobTrigger = "timer"
+	JobTriggerAPI     JobTrigger = "api"
+	JobTriggerSCN     JobTrigger = "scn"
+	JobTriggerKeys    JobTrigger = "keys"
+	JobTriggerRecords JobTrigger = "records"
+)
+
+// JobPhase is where a true up run is at.
+type JobPhase string
+
+const (
+	JobPhasePending     JobPhase = "pending"
+	JobPhaseFetching    JobPhase = "fetching"
+	JobPhaseZones       JobPhase = "zones"
+	JobPhaseBuilding    JobPhase = "building"
+	JobPhaseReconciling JobPhase = "reconciling"
+	JobPhaseNotifying   JobPhase = "notifying"
+	JobPhaseSucceeded   JobPhase = "succeeded"
+	JobPhaseFailed      JobPhase = "failed"
+)
+
+// JobCounts are the number of zones and RRsets changed by a true up run.
+type JobCounts struct {
+	ZonesCreated     int `json:"zones_created"`
+	ZonesReplaced    int `json:"zones_replaced"`
+	ZonesDeleted     int `json:"zones_deleted"`
+	ZonesQuarantined int `json:"zones_quarantined"`
+	RRsetsCreated    int `json:"rrsets_created"`
+	RRsetsReplaced   int `json:"rrsets_replaced"`
+	RRsetsDeleted    int `json:"rrsets_deleted"`
+}
+
+// Job is the record of a single true up run.
+type Job struct {
+	ID        string     `json:"id"`
+	Trigger   JobTrigger `json:"trigger"`
+	DryRun    bool       `json:"dry_run"`
+	Phase     JobPhase   `json:"phase"`
+	StartTime *time.Time `json:"start_time,omitempty"`
+	EndTime   *time.Time `json:"end_time,omitempty"`
+	Counts    JobCounts  `json:"counts"`
+	Errors    []string   `json:"errors,omitempty"`
+
+	// Components limits the run to the given components, only set for runs triggered by HSM state changes.
+	Components []string `json:"components,omitempty"`
+}
+
+var (
+	// jobHistory is ordered oldest to newest and never grows beyond job_history_size.
+	jobHistory []*Job
+	jobsMtx    sync.Mutex
+)
+
+func newJobID() string {
+	idBytes := make([]byte, 8)
+	_, err := rand.Read(idBytes)
+	if err != nil {
+		// Should never happen, but, if it does fall back to something that is still unique enough.
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+
+	return hex.EncodeToString(idBytes)
+}
+
+// newJob creates a pending job and adds it to the history, dropping the oldest job if the history is full.
+func newJob(trigger JobTrigger, componentIDs ...string) *Job {
+	job := &Job{
+		ID:         newJobID(),
+		Trigger:    trigger,
+		DryRun:     *dryRun,
+		Phase:      JobPhasePending,
+		Components: componentIDs,
+	}
+
+	jobsMtx.Lock()
+	defer jobsMtx.Unlock()
+
+	jobHistory = append(jobHistory, job)
+	if *jobHistorySize > 0 && len(jobHistory) > *jobHistorySize {
+		jobHistory = jobHistory[len(jobHistory)-*jobHistorySize:]
+	}
+
+	return job
+}
+
+// setPhase moves the job to the given phase. It is safe to call on a nil job which is what plan requests use.
+func (job *Job) setPhase(phase JobPhase) {
+	if job == nil {
+		return
+	}
+
+	jobsMtx.Lock()
+	defer jobsMtx.Unlock()
+
+	if job.StartTime == nil {
+		now := time.Now()
+		job.StartTime = &now
+	}
+	job.Phase = phase
+}
+
+// finish records the outcome of the run from the plan and any error that stopped it. The final phase is returned as the
+// job can't be read without holding the lock once the API can see it.
+func (job *Job) finish(plan *Plan, err error) (phase JobPhase) {
+	if job == nil {
+		return
+	}
+
+	jobsMtx.Lock()
+	defer jobsMtx.Unlock()
+
+	now := time.Now()
+	if job.StartTime == nil {
+		job.StartTime = &now
+	}
+	job.EndTime = &now
+
+	if err != nil {
+		job.Errors = append(job.Errors, err.Error())
+	}
+
+	if plan != nil {
+		for _, zonePlan := range plan.Zones {
+			if zonePlan.Error != "" {
+				job.Errors = append(job.Errors, fmt.Sprintf("%s: %s", zonePlan.Name, zonePlan.Error))
+				continue
+			}
+
+			if zonePlan.CreateZone {
+				job.Counts.ZonesCreated++
+			}
+			if zonePlan.ReplaceZone {
+				job.Counts.ZonesReplaced++
+			}
+			if zonePlan.DeleteZone {
+				job.Counts.ZonesDeleted++
+			}
+			if zonePlan.QuarantineZone {
+				job.Counts.ZonesQuarantined++
+			}
+			job.Counts.RRsetsCreated += len(zonePlan.Creates)
+			job.Counts.RRsetsReplaced += len(zonePlan.Replaces)
+			job.Counts.RRsetsDeleted += len(zonePlan.Deletes)
+		}
+	}
+
+	if len(job.Errors) > 0 {
+		job.Phase = JobPhaseFailed
+	} else {
+		job.Phase = JobPhaseSucceeded
+	}
+
+	return job.Phase
+}
+
+// getJobs returns a copy of the job history, newest first.
+func getJobs() []Job {
+	jobsMtx.Lock()
+	defer jobsMtx.Unlock()
+
+	jobs := make([]Job, 0, len(jobHistory))
+	for i := len(jobHistory) - 1; i >= 0; i-- {
+		jobs = append(jobs, *jobHistory[i])
+	}
+
+	return jobs
+}
+
+// getJob returns a copy of the job with the given ID.
+func getJob(id string) (job Job, found bool) {
+	jobsMtx.Lock()
+	defer jobsMtx.Unlock()
+
+	for _, historicJob := range jobHistory {
+		if historicJob.ID == id {
+			return *historicJob, true
+		}
+	}
+
+	return
+}
@@ -0,0 +1,359 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Cray-HPE/cray-powerdns-manager/internal/common"
+	"github.com/fsnotify/fsnotify"
+	"github.com/joeig/go-powerdns/v2"
+	"go.uber.org/zap"
+)
+
+// Kubernetes updates a mounted secret with a flurry of events, wait for it to settle before reloading.
+const keyReloadSettleTime = 2 * time.Second
+
+// KeyStatus is what happened the last time a key from the key directory was loaded.
+type KeyStatus struct {
+	Name        string     `json:"name"`
+	Type        string     `json:"type"`
+	Fingerprint string     `json:"fingerprint"`
+	Zones       []string   `json:"zones,omitempty"`
+	Secondaries []string   `json:"secondaries,omitempty"`
+	Valid       bool       `json:"valid"`
+	LoadedAt    time.Time  `json:"loaded_at"`
+	PushedAt    *time.Time `json:"pushed_at,omitempty"`
+	Error       string     `json:"error,omitempty"`
+}
+
+var (
+	keyStatuses    []KeyStatus
+	keyStatusesMtx sync.Mutex
+
+	// keysLoaded is set once the key directory has been read successfully, until then the keys in memory say nothing
+	// about which keys should be in PowerDNS.
+	keysLoaded bool
+
+	// loadedTSIGKeys are the names of every TSIG key loaded from the key directory since the manager started. Along with
+	// the keys recorded in each zone's metadata, see getZoneOwnedTSIGKeys, only these are the manager's to remove,
+	// anything else in PowerDNS was put there by someone else.
+	loadedTSIGKeys = make(map[string]bool)
+)
+
+func getKeyTypeName(keyType common.DNSKeyType) string {
+	if keyType == common.TSIGKeyType {
+		return "tsig"
+	}
+
+	return "dnssec"
+}
+
+// getKeyFingerprint identifies the contents of a key without giving any of it away.
+func getKeyFingerprint(key common.DNSKey) string {
+	sum := sha256.Sum256([]byte(key.Data))
+	return hex.EncodeToString(sum[:8])
+}
+
+// haveKeysLoaded returns true if the key directory has been read successfully at least once.
+func haveKeysLoaded() bool {
+	keyStatusesMtx.Lock()
+	defer keyStatusesMtx.Unlock()
+
+	return keysLoaded
+}
+
+// normalizeKeyName makes key names comparable regardless of case or a trailing dot.
+func normalizeKeyName(keyName string) string {
+	return strings.ToLower(common.MakeDomainCanonical(keyName))
+}
+
+// isLoadedTSIGKey returns true if the TSIG key was loaded from the key directory at some point.
+func isLoadedTSIGKey(keyName string) bool {
+	keyStatusesMtx.Lock()
+	defer keyStatusesMtx.Unlock()
+
+	return loadedTSIGKeys[normalizeKeyName(keyName)]
+}
+
+// getKeyStatuses returns a copy of the status of every key.
+func getKeyStatuses() []KeyStatus {
+	keyStatusesMtx.Lock()
+	defer keyStatusesMtx.Unlock()
+
+	return append([]KeyStatus{}, keyStatuses...)
+}
+
+// normalizePrivateKey strips the whitespace differences between a key file and what PowerDNS gives back.
+func normalizePrivateKey(privateKey string) string {
+	return strings.Join(strings.Fields(privateKey), " ")
+}
+
+// findCryptokey returns the ID of the cryptokey in the zone with the given private key.
+func findCryptokey(zoneName string, privateKey string) (id uint64, found bool, err error) {
+	cryptokeys, err := pdns.Cryptokeys.List(zoneName)
+	recordPowerDNSResult(err)
+	if err != nil {
+		err = fmt.Errorf("failed to list cryptokeys: %w", err)
+		return
+	}
+
+	for _, cryptokey := range cryptokeys {
+		if cryptokey.ID == nil {
+			continue
+		}
+
+		// Only getting a single key includes the private key.
+		var fullCryptokey *powerdns.Cryptokey
+		fullCryptokey, err = pdns.Cryptokeys.Get(zoneName, *cryptokey.ID)
+		recordPowerDNSResult(err)
+		if err != nil {
+			err = fmt.Errorf("failed to get cryptokey %d: %w", *cryptokey.ID, err)
+			return
+		}
+
+		if fullCryptokey.Privatekey != nil &&
+			normalizePrivateKey(*fullCryptokey.Privatekey) == normalizePrivateKey(privateKey) {
+			return *cryptokey.ID, true, nil
+		}
+	}
+
+	return
+}
+
+// attachDNSSECKey adds the key to its zone if the zone exists and doesn't already have it. If the key replaced an older
+// one for the same zone the old one is deactivated.
+func attachDNSSECKey(key common.DNSKey, previousKey *common.DNSKey) error {
+	_, err := pdns.Zones.Get(key.Name)
+	recordPowerDNSResult(err)
+	if err != nil {
+		pdnsErr, ok := err.(*powerdns.Error)
+		if ok && pdnsErr.StatusCode == http.StatusNotFound {
+			// The key will be added when the zone is created.
+			return nil
+		}
+		return fmt.Errorf("failed to get zone: %w", err)
+	}
+
+	_, found, err := findCryptokey(key.Name, key.Data)
+	if err != nil {
+		return err
+	}
+	if !found {
+		err = AddCryptokeyToZone(key)
+		if err != nil {
+			return err
+		}
+		logger.Info("Attached DNSSEC key to existing zone", zap.String("key", key.Name))
+	}
+
+	if previousKey != nil {
+		previousID, previousFound, findErr := findCryptokey(key.Name, previousKey.Data)
+		if findErr != nil {
+			return findErr
+		}
+		if previousFound {
+			err = setCryptokeyActive(key.Name, previousID, false)
+			if err != nil {
+				return err
+			}
+			logger.Info("Deactivated replaced DNSSEC key", zap.String("key", key.Name),
+				zap.Uint64("cryptokeyID", previousID))
+		}
+	}
+
+	return nil
+}
+
+// loadKeys parses the key directory and pushes anything new or changed to PowerDNS. On startup every TSIG key is pushed
+// and DNSSEC keys are left to be added when their zone is created, after that only the keys that changed are pushed.
+func loadKeys(startup bool) {
+	previousKeys := make(map[string]common.DNSKey)
+	for _, key := range getDNSKeys() {
+		previousKeys[key.Name] = key
+	}
+
+	keyErrors, err := ParseDNSKeys()
+	if err != nil {
+		logger.Error("Failed to parse DNSSEC keys directory!", zap.Error(err))
+		return
+	}
+
+	keyStatusesMtx.Lock()
+	keysLoaded = true
+	for _, key := range getDNSKeys() {
+		if key.Type == common.TSIGKeyType {
+			loadedTSIGKeys[normalizeKeyName(key.Name)] = true
+		}
+	}
+	keyStatusesMtx.Unlock()
+
+	now := time.Now()
+	var statuses []KeyStatus
+
+	// Invalid keys are reported on their own, any previous valid version of the key that is still in use is reported
+	// separately below.
+	for _, keyError := range keyErrors {
+		logger.Error("Rejected invalid key!", zap.String("key", keyError.Name), zap.Error(keyError.Err))
+		statuses = append(statuses, KeyStatus{
+			Name:     keyError.Name,
+			Type:     getKeyTypeName(keyError.Type),
+			Valid:    false,
+			LoadedAt: now,
+			Error:    keyError.Err.Error(),
+		})
+	}
+	for _, key := range getDNSKeys() {
+		status := KeyStatus{
+			Name:        key.Name,
+			Type:        getKeyTypeName(key.Type),
+			Fingerprint: getKeyFingerprint(key),
+			Zones:       key.Zones,
+			Secondaries: key.Secondaries,
+			Valid:       true,
+			LoadedAt:    now,
+		}
+
+		previousKey, previousFound := previousKeys[key.Name]
+		changed := !previousFound || previousKey.Data != key.Data || previousKey.Type != key.Type
+
+		if !changed {
+			// Nothing to do, keep what happened last time.
+			for _, previousStatus := range getKeyStatuses() {
+				if previousStatus.Name == key.Name && previousStatus.Valid {
+					status.LoadedAt = previousStatus.LoadedAt
+					status.PushedAt = previousStatus.PushedAt
+					status.Error = previousStatus.Error
+				}
+			}
+			statuses = append(statuses, status)
+			continue
+		}
+
+		keyLogger := logger.With(zap.String("key", key.Name), zap.String("type", status.Type),
+			zap.String("fingerprint", status.Fingerprint))
+		if key.Type == common.TSIGKeyType {
+			keyLogger.Info("Parsed TSIG key")
+			err = AddOrUpdateTSIGKey(key)
+		} else {
+			keyLogger.Info("Parsed DNSSEC key")
+			if !startup {
+				var replacedKey *common.DNSKey
+				if previousFound && previousKey.Type == key.Type {
+					replacedKey = &previousKey
+				}
+				err = attachDNSSECKey(key, replacedKey)
+			}
+		}
+
+		if err != nil {
+			keyLogger.Error("Failed to push key to PowerDNS!", zap.Error(err))
+			status.Error = err.Error()
+		} else if key.Type == common.TSIGKeyType || !startup {
+			pushedAt := now
+			status.PushedAt = &pushedAt
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	for _, key := range getDNSKeys() {
+		delete(previousKeys, key.Name)
+	}
+	for name, key := range previousKeys {
+		logger.Info("Key removed from key directory", zap.String("key", name))
+
+		// The true up queued after a reload removes the key from the zones.
+		if key.Type == common.TSIGKeyType {
+			err = DeleteTSIGKey(name)
+			if err != nil {
+				logger.Error("Failed to delete removed TSIG key!", zap.Error(err), zap.String("key", name))
+			}
+		}
+	}
+
+	keyStatusesMtx.Lock()
+	keyStatuses = statuses
+	keyStatusesMtx.Unlock()
+}
+
+// watchKeyDirectory reloads the keys whenever the key directory changes and queues a true up so the zones pick up any
+// changes to the TSIG keys.
+func watchKeyDirectory() {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		logger.Error("Failed to create key directory watcher, keys will not be reloaded!", zap.Error(err))
+		return
+	}
+	defer watcher.Close()
+
+	err = watcher.Add(*keyDirectory)
+	if err != nil {
+		logger.Error("Failed to watch key directory, keys will not be reloaded!", zap.Error(err),
+			zap.String("keyDirectory", *keyDirectory))
+		return
+	}
+
+	logger.Info("Watching key directory for changes.", zap.String("keyDirectory", *keyDirectory))
+
+	var settle <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			logger.Debug("Key directory changed", zap.String("event", event.String()))
+			settle = time.After(keyReloadSettleTime)
+		case watchErr, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			logger.Error("Error watching key directory!", zap.Error(watchErr))
+		case <-settle:
+			settle = nil
+
+			logger.Info("Reloading keys.")
+			loadKeys(false)
+
+			trueUpMtx.Lock()
+			if len(trueUpRunNow) == 0 {
+				trueUpRunNow <- newJob(JobTriggerKeys)
+			} else {
+				logger.Debug("True up already queued, not queueing another for the key reload.")
+			}
+			trueUpMtx.Unlock()
+		}
+	}
+}
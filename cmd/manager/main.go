@@ -28,8 +28,10 @@ package main
 import (
 	"context"
 	"crypto/tls"
+	"fmt"
 	"github.com/Cray-HPE/cray-powerdns-manager/internal/common"
 	"github.com/Cray-HPE/cray-powerdns-manager/internal/httpLogger"
+	"github.com/Cray-HPE/cray-powerdns-manager/internal/metrics"
 	"github.com/gin-gonic/gin"
 	"github.com/hashicorp/go-retryablehttp"
 	"github.com/joeig/go-powerdns/v2"
@@ -54,6 +56,10 @@ var (
 		"Comma separated list of secondary DNS name/IPs")
 	notifyZones = flag.String("notify_zones", "",
 		"Comma separated list of zones for which a DNS NOTIFY should be sent to the slave servers")
+	notifyRetries = flag.Int("notify_retries", 3,
+		"Number of times to retry a failed DNS NOTIFY for a zone before giving up until it next changes")
+	notifyRetryInterval = flag.Int("notify_retry_interval", 2,
+		"Number of seconds to wait before the first NOTIFY retry, doubled for every retry after that")
 	keyDirectory = flag.String("key_directory", "./keys",
 		"Path to directory containing ICS formatted private DNSSEC keys for one or more zones")
 
@@ -83,8 +89,103 @@ var (
 	soaMinimum = flag.String("soa_minimum", "3600",
 		"The negative result TTL")
 
+	ttlA        = flag.Uint("ttl_a", uint(common.DefaultTTL), "TTL of generated A and AAAA RRsets")
+	ttlCNAME    = flag.Uint("ttl_cname", uint(common.DefaultTTL), "TTL of generated CNAME RRsets")
+	ttlPTR      = flag.Uint("ttl_ptr", uint(common.DefaultTTL), "TTL of generated PTR RRsets")
+	ttlNS       = flag.Uint("ttl_ns", uint(common.DefaultTTL), "TTL of generated NS RRsets")
+	ttlDNAME    = flag.Uint("ttl_dname", uint(common.DefaultTTL), "TTL of generated DNAME RRsets")
+	ttlNetworks = flag.String("ttl_networks", "",
+		"Comma separated list of SLS network=ttl pairs (e.g., hmn=300,nmn=86400) overriding the TTL of the A, AAAA, "+
+			"CNAME, and PTR RRsets generated for those networks")
+
 	nidPrefix = flag.String("nid_prefix", "nid", "Prefix to use to search SLS for NID aliases")
 
+	removeStaleRecords = flag.Bool("remove_stale_records", true,
+		"Remove A, AAAA, CNAME, and PTR records created by the manager that are no longer justified by SLS or HSM")
+	jobHistorySize = flag.Int("job_history_size", 100,
+		"Number of true up jobs to keep in the history")
+
+	dryRun = flag.Bool("dry_run", false,
+		"Compute and log the changes the true up loop would make without making any of them")
+
+	ownershipRefreshInterval = flag.Int("ownership_refresh_interval", 0,
+		"Number of seconds after which the last seen time of an unchanged record is refreshed, 0 to never refresh "+
+			"it as every refresh patches the zone and notifies the secondaries")
+	dependencyFailureThreshold = flag.Int("dependency_failure_threshold", 300,
+		"Number of seconds PowerDNS, SLS, or HSM can be failing before the manager reports it is not ready")
+
+	rfc2317ReverseZones = flag.Bool("rfc2317_reverse_zones", false,
+		"Give networks smaller than a /24 their own RFC 2317 classless reverse zone delegated from the /24 zone")
+
+	incrementalTrueUp = flag.Bool("incremental", false,
+		"Only diff and patch what changed since the last true up run instead of fetching every zone each time, SLS "+
+			"and HSM are still fetched every run but nothing is rebuilt if they haven't changed. State change "+
+			"notification runs use the cached zones too")
+	fullResyncInterval = flag.Int("full_resync_interval", 3600,
+		"Number of seconds between full true up runs when running incrementally")
+
+	hsmSCNSubscribe = flag.Bool("hsm_scn_subscribe", false,
+		"Subscribe to HSM state change notifications and true up the affected components as they arrive")
+	hsmSCNCallbackURL = flag.String("hsm_scn_callback_url", "http://cray-powerdns-manager/v1/manager/scn",
+		"URL HSM should send state change notifications to")
+	hsmSCNStates = flag.String("hsm_scn_states", "Populated,On,Ready",
+		"Comma separated list of HSM component states to receive state change notifications for")
+
+	soaEditAPI = flag.String("soa_edit_api", "INCEPTION-INCREMENT",
+		"SOA-EDIT-API for every zone so PowerDNS bumps the SOA serial of a zone each time it is changed, empty leaves "+
+			"it to the PowerDNS default")
+
+	dnssecEnabled = flag.Bool("dnssec", false,
+		"Sign every zone without a custom key in the key directory with a KSK and ZSK managed by the manager")
+	dnssecAlgorithm = flag.String("dnssec_algorithm", "ecdsap256sha256",
+		"Algorithm for the keys the manager generates")
+	dnssecKSKRolloverInterval = flag.Int("dnssec_ksk_rollover_interval", 0,
+		"Number of seconds after which a KSK is rolled over, 0 to never roll KSKs over")
+	dnssecZSKRolloverInterval = flag.Int("dnssec_zsk_rollover_interval", 2592000,
+		"Number of seconds after which a ZSK is rolled over, 0 to never roll ZSKs over")
+	dnssecKSKDoubleSignaturePeriod = flag.Int("dnssec_ksk_double_signature_period", 604800,
+		"Number of seconds the old and new KSK both sign during a rollover, the DS at the parent must be updated in "+
+			"this time")
+	dnssecPropagationDelay = flag.Int("dnssec_propagation_delay", 86400,
+		"Number of seconds a new ZSK is published before it is used and an old key is kept after it is retired")
+	nsec3Param = flag.String("nsec3_param", "",
+		"NSEC3PARAM for signed zones (e.g., \"1 0 0 -\"), empty to use NSEC")
+
+	watchKeys = flag.Bool("watch_keys", true,
+		"Watch the key directory and reload the keys whenever it changes")
+
+	orphanedZoneAction = flag.String("orphaned_zone_action", OrphanedZoneActionNone,
+		"What to do with zones the manager created that no longer correspond to anything in SLS: "+
+			"none, quarantine (set the zone account to mark it), or delete")
+	orphanedZoneDeleteLimit = flag.Int("orphaned_zone_delete_limit", 2,
+		"Most zones that can be deleted as orphaned, or stop being desired, in one run. Any more and nothing is "+
+			"deleted as SLS losing networks is more likely than that many being removed at once")
+
+	conflictPrecedence = flag.String("conflict_precedence", ConflictPrecedenceHSM,
+		"Which source wins when SLS and HSM generate conflicting RRsets for the same name: sls or hsm")
+
+	staticRecordsFile = flag.String("static_records_file", "",
+		"YAML or JSON file of extra zones and RRsets to add to the desired state, reloaded every true up run")
+
+	renderSLSFile = flag.String("render_sls_file", "",
+		"Instead of running, render every zone from this SLS dump file (the output of the SLS dumpstate endpoint) "+
+			"without talking to PowerDNS, SLS, or HSM and exit")
+	renderHSMEthernetInterfacesFile = flag.String("render_hsm_ethernet_interfaces_file", "",
+		"HSM ethernet interfaces dump file (the output of /hsm/v2/Inventory/EthernetInterfaces) to render from")
+	renderHSMStateFile = flag.String("render_hsm_state_file", "",
+		"HSM component state dump file (the output of /hsm/v2/State/Components) to render from")
+	renderFormat = flag.String("render_format", RenderFormatBIND,
+		"Format to render the zones in: bind (zone files) or json (the RRsets of every zone)")
+	renderOutputDir = flag.String("render_output_dir", "",
+		"Directory to write the rendered zones to, empty for stdout")
+
+	backupDir = flag.String("backup_dir", "",
+		"Instead of running, back up every zone the manager owns with its cryptokeys, metadata, and TSIG keys to a new "+
+			"directory under this one and exit, the backup includes private keys")
+	restoreDir = flag.String("restore_dir", "",
+		"Instead of running, restore the backup in this directory into PowerDNS and exit, zones that already exist "+
+			"are left alone")
+
 	router *gin.Engine
 
 	pdns *powerdns.Client
@@ -94,6 +195,8 @@ var (
 	atomicLevel zap.AtomicLevel
 	logger      *zap.Logger
 
+	managerMetrics *metrics.Metrics
+
 	Running   = true
 	WaitGroup sync.WaitGroup
 	ctx       context.Context
@@ -101,14 +204,22 @@ var (
 	APIServer *http.Server = nil
 
 	trueUpShutdown   chan bool
-	trueUpRunNow     chan bool
+	trueUpRunNow     chan *Job
+	trueUpSCNRunNow  chan bool
 	trueUpInProgress bool
 	trueUpMtx        sync.Mutex
+	// trueUpRunMtx is held for the whole of every run, plans included, so only one ever talks to PowerDNS at a time.
+	trueUpRunMtx sync.Mutex
 
 	token string
 
 	notifyZonesArray       []string
 	ignoreSLSNetworksArray []string
+
+	masterNameserver common.Nameserver
+	slaveNameservers []common.Nameserver
+
+	recordTTLs common.TTLs
 )
 
 func setupLogging() {
@@ -117,10 +228,16 @@ func setupLogging() {
 
 	atomicLevel = zap.NewAtomicLevel()
 
+	// Rendered zones may be written to stdout so the log has to go somewhere else.
+	logOutput := os.Stdout
+	if *renderSLSFile != "" {
+		logOutput = os.Stderr
+	}
+
 	encoderCfg := zap.NewProductionEncoderConfig()
 	logger = zap.New(zapcore.NewCore(
 		zapcore.NewJSONEncoder(encoderCfg),
-		zapcore.Lock(os.Stdout),
+		zapcore.Lock(logOutput),
 		atomicLevel,
 	))
 
@@ -149,6 +266,64 @@ func setupLogging() {
 	}
 }
 
+// parseNameservers builds the master and slave nameservers from the command line arguments.
+func parseNameservers() {
+	if *masterServer != "" {
+		masterNameserverSplit := strings.Split(*masterServer, "/")
+		if len(masterNameserverSplit) != 2 {
+			logger.Fatal("Master nameserver does not have name/IP format!",
+				zap.String("masterServer", *masterServer))
+		}
+		masterNameserver = common.Nameserver{
+			FQDN: fmt.Sprintf("%s.%s", masterNameserverSplit[0], *baseDomain),
+			IP:   masterNameserverSplit[1],
+		}
+	}
+
+	if *slaveServers != "" {
+		for _, slaveServer := range strings.Split(*slaveServers, ",") {
+			nameserverSplit := strings.Split(slaveServer, "/")
+			if len(nameserverSplit) != 2 {
+				logger.Fatal("Slave nameserver does not have FQDN/IP format!",
+					zap.String("slaveServer", slaveServer))
+			}
+			slaveNameserver := common.Nameserver{
+				FQDN: common.MakeDomainCanonical(nameserverSplit[0]),
+				IP:   nameserverSplit[1],
+			}
+
+			slaveNameservers = append(slaveNameservers, slaveNameserver)
+		}
+	}
+}
+
+// parseTTLs builds the TTLs of the generated RRsets from the command line arguments.
+func parseTTLs() {
+	recordTTLs = common.TTLs{Types: make(map[powerdns.RRType]uint32)}
+
+	typeTTLs := map[powerdns.RRType]*uint{
+		powerdns.RRTypeA:     ttlA,
+		powerdns.RRTypeAAAA:  ttlA,
+		powerdns.RRTypeCNAME: ttlCNAME,
+		powerdns.RRTypePTR:   ttlPTR,
+		powerdns.RRTypeNS:    ttlNS,
+		powerdns.RRTypeDNAME: ttlDNAME,
+	}
+	for rrType, ttl := range typeTTLs {
+		validTTL, err := common.ValidateTTL(*ttl)
+		if err != nil {
+			logger.Fatal("Invalid TTL!", zap.String("type", string(rrType)), zap.Error(err))
+		}
+		recordTTLs.Types[rrType] = validTTL
+	}
+
+	networkTTLs, err := common.ParseNetworkTTLs(*ttlNetworks)
+	if err != nil {
+		logger.Fatal("Invalid network TTLs!", zap.String("ttlNetworks", *ttlNetworks), zap.Error(err))
+	}
+	recordTTLs.Networks = networkTTLs
+}
+
 func main() {
 	// Parse the arguments.
 	flag.Parse()
@@ -156,15 +331,68 @@ func main() {
 	// Setup logging.
 	setupLogging()
 
+	managerMetrics = metrics.NewMetrics("cray_powerdns_manager")
+
 	token = os.Getenv("TOKEN")
 
+	parseNameservers()
+	parseTTLs()
+
+	switch *orphanedZoneAction {
+	case OrphanedZoneActionNone, OrphanedZoneActionQuarantine, OrphanedZoneActionDelete:
+	default:
+		logger.Fatal("Invalid orphaned zone action!", zap.String("orphanedZoneAction", *orphanedZoneAction))
+	}
+
+	switch *conflictPrecedence {
+	case ConflictPrecedenceSLS, ConflictPrecedenceHSM:
+	default:
+		logger.Fatal("Invalid conflict precedence!", zap.String("conflictPrecedence", *conflictPrecedence))
+	}
+
+	// Compute an array of the zones for which to notify.
+	if *notifyZones != "" {
+		notifyZonesArray = strings.Split(*notifyZones, ",")
+	}
+	if len(notifyZonesArray) == 0 {
+		logger.Info("Sending DNS NOTIFY for all zones")
+	} else {
+		logger.Info("Sending DNS NOTIFY for zones", zap.Strings("notifyZonesArray", notifyZonesArray))
+	}
+
+	// Build a list of SLS networks to ignore.
+	if *ignoreSLSNetworks != "" {
+		ignoreSLSNetworksArray = strings.Split(*ignoreSLSNetworks, ",")
+		logger.Debug("Excluding the following SLS networks from zone generation", zap.Strings("ignoreSLSNetworksArray", ignoreSLSNetworksArray))
+	}
+
+	// Catch a broken static records file now rather than on every true up run.
+	if *staticRecordsFile != "" {
+		staticRecords, err := loadStaticRecords(*staticRecordsFile)
+		if err != nil {
+			logger.Fatal("Failed to load static records!", zap.Error(err))
+		}
+		logger.Info("Loaded static records", zap.Strings("zones", staticRecords.Zones),
+			zap.Int("numRecords", len(staticRecords.Records)))
+	}
+
+	// Rendering is a one shot job that needs nothing but the files it is given.
+	if *renderSLSFile != "" {
+		err := runRender()
+		if err != nil {
+			logger.Fatal("Failed to render zones!", zap.Error(err))
+		}
+		return
+	}
+
 	var cancel context.CancelFunc
 	ctx, cancel = context.WithCancel(context.Background())
 
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, syscall.SIGINT, syscall.SIGTERM)
 	trueUpShutdown = make(chan bool)
-	trueUpRunNow = make(chan bool, 1)
+	trueUpRunNow = make(chan *Job, 1)
+	trueUpSCNRunNow = make(chan bool, 1)
 
 	go func() {
 		<-c
@@ -187,11 +415,6 @@ func main() {
 		}
 	}()
 
-	// Add to the wait group so we spin on it later.
-	WaitGroup.Add(1)
-	logger.Info("Starting API server.")
-	setupAPI()
-
 	// For performance reasons we'll keep the client that was created for this base request and reuse it later.
 	httpClient = retryablehttp.NewClient()
 	transport := &http.Transport{
@@ -210,44 +433,37 @@ func main() {
 	pdns = powerdns.NewClient(*pdnsURL, "localhost", map[string]string{"X-API-Key": *pdnsAPIKey},
 		httpClient.StandardClient())
 
-	// Parse any DNSSEC keys.
-	err := ParseDNSKeys()
-	if err != nil {
-		logger.Error("Failed to parse DNSSEC keys directory!", zap.Error(err))
-	} else {
-		for _, key := range DNSKeys {
-			if key.Type == common.TSIGKeyType {
-				logger.Info("Parsed TSIG key", zap.Any("key", key))
-			} else {
-				logger.Info("Parsed DNSSEC key", zap.Any("key", key))
-			}
+	// Backups and restores are one shot jobs against PowerDNS.
+	if *backupDir != "" {
+		backupPath, err := runBackup(*backupDir)
+		if err != nil {
+			logger.Fatal("Failed to back up zones!", zap.Error(err))
 		}
+		logger.Info("Backed up zones", zap.String("backupPath", backupPath))
+		return
 	}
-
-	// If there are any TSIG keys, load them into PowerDNS.
-	for _, key := range DNSKeys {
-		if key.Type == common.TSIGKeyType {
-			err := AddOrUpdateTSIGKey(key)
-			if err != nil {
-				logger.Error("Failed to add TSIG key!", zap.Error(err), zap.Any("key", key))
-			}
+	if *restoreDir != "" {
+		err := runRestore(*restoreDir)
+		if err != nil {
+			logger.Fatal("Failed to restore zones!", zap.Error(err))
 		}
+		return
 	}
 
-	// Compute an array of the zones for which to notify.
-	if *notifyZones != "" {
-		notifyZonesArray = strings.Split(*notifyZones, ",")
-	}
-	if len(notifyZonesArray) == 0 {
-		logger.Info("Sending DNS NOTIFY for all zones")
-	} else {
-		logger.Info("Sending DNS NOTIFY for zones", zap.Strings("notifyZonesArray", notifyZonesArray))
+	// Add to the wait group so we spin on it later.
+	WaitGroup.Add(1)
+	logger.Info("Starting API server.")
+	setupAPI()
+
+	// Parse any DNSSEC keys and load the TSIG ones into PowerDNS.
+	loadKeys(true)
+	if *watchKeys {
+		go watchKeyDirectory()
 	}
 
-	// Build a list of SLS networks to ignore.
-	if *ignoreSLSNetworks != "" {
-		ignoreSLSNetworksArray = strings.Split(*ignoreSLSNetworks, ",")
-		logger.Debug("Excluding the following SLS networks from zone generation", zap.Strings("ignoreSLSNetworksArray", ignoreSLSNetworksArray))
+	// Event driven true ups are in addition to the loop, not instead of it.
+	if *hsmSCNSubscribe {
+		go maintainHSMSCNSubscription()
 	}
 
 	// Kick off the true up loop.
@@ -256,7 +472,7 @@ func main() {
 	go trueUpDNS()
 
 	// Seed the fist run since we start the loop with the select block.
-	trueUpRunNow <- true
+	trueUpRunNow <- newJob(JobTriggerStartup)
 
 	// We'll spend pretty much the rest of life blocking on the next line.
 	WaitGroup.Wait()
@@ -0,0 +1,80 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/Cray-HPE/cray-powerdns-manager/internal/common"
+	"github.com/joeig/go-powerdns/v2"
+	"go.uber.org/zap"
+)
+
+func TestMain(m *testing.M) {
+	logger = zap.NewNop()
+
+	*baseDomain = "example.com"
+	*createDNAME = false
+	masterNameserver = common.Nameserver{FQDN: "ns1.example.com", IP: "10.92.100.71"}
+	slaveNameservers = nil
+	recordTTLs = common.TTLs{}
+
+	os.Exit(m.Run())
+}
+
+// newTestRRset returns an RRset with the given ownership and records.
+func newTestRRset(name string, rrType powerdns.RRType, ownership common.RRsetOwnership,
+	contents ...string) powerdns.RRset {
+	rrSet := powerdns.RRset{
+		Name:       powerdns.String(name),
+		Type:       powerdns.RRTypePtr(rrType),
+		TTL:        powerdns.Uint32(common.DefaultTTL),
+		ChangeType: powerdns.ChangeTypePtr(powerdns.ChangeTypeReplace),
+		Comments:   common.GetOwnershipComments(ownership),
+	}
+	for _, content := range contents {
+		rrSet.Records = append(rrSet.Records, powerdns.Record{
+			Content:  powerdns.String(content),
+			Disabled: powerdns.Bool(false),
+		})
+	}
+
+	return rrSet
+}
+
+// getTestRRsetContents returns the records of every RRset by name and type.
+func getTestRRsetContents(rrSets []powerdns.RRset) map[string][]string {
+	contents := make(map[string][]string)
+	for _, rrSet := range rrSets {
+		key := *rrSet.Name + " " + string(*rrSet.Type)
+		for _, record := range rrSet.Records {
+			contents[key] = append(contents[key], *record.Content)
+		}
+	}
+
+	return contents
+}
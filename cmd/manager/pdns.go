@@ -0,0 +1,97 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/hashicorp/go-retryablehttp"
+	"github.com/joeig/go-powerdns/v2"
+)
+
+// doPowerDNSRequest makes a request to the PowerDNS API for the few things the client library doesn't cover. The path is
+// relative to the server, e.g. "zones/example.com./metadata". Failures are returned as a *powerdns.Error just like the
+// client library does so they are handled the same way.
+func doPowerDNSRequest(method string, path string, requestBody interface{}, responseBody interface{}) (err error) {
+	defer func() {
+		recordPowerDNSResult(err)
+	}()
+
+	var reqBody []byte
+	if requestBody != nil {
+		reqBody, err = json.Marshal(requestBody)
+		if err != nil {
+			err = fmt.Errorf("failed to marshal request body: %w", err)
+			return
+		}
+	}
+
+	url := fmt.Sprintf("%s/api/v1/servers/localhost/%s", strings.TrimSuffix(*pdnsURL, "/"), path)
+	req, err := retryablehttp.NewRequest(method, url, bytes.NewReader(reqBody))
+	if err != nil {
+		err = fmt.Errorf("failed to create new request: %w", err)
+		return
+	}
+	req.Header.Set("X-API-Key", *pdnsAPIKey)
+	if requestBody != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req = req.WithContext(ctx)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		err = fmt.Errorf("failed to do request: %w", err)
+		return
+	}
+	defer resp.Body.Close()
+
+	body, _ := ioutil.ReadAll(resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		pdnsErr := &powerdns.Error{
+			Status:     resp.Status,
+			StatusCode: resp.StatusCode,
+		}
+		if json.Unmarshal(body, pdnsErr) != nil || pdnsErr.Message == "" {
+			pdnsErr.Message = string(body)
+		}
+		err = pdnsErr
+		return
+	}
+
+	if responseBody != nil && resp.StatusCode != http.StatusNoContent && len(body) > 0 {
+		err = json.Unmarshal(body, responseBody)
+		if err != nil {
+			err = fmt.Errorf("failed to unmarshal body: %w", err)
+		}
+	}
+
+	return
+}
@@ -0,0 +1,166 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package main
+
+import (
+	"sort"
+
+	"github.com/Cray-HPE/cray-powerdns-manager/internal/common"
+	"github.com/joeig/go-powerdns/v2"
+	"go.uber.org/zap"
+)
+
+// ZonePlan is every change a true up run wants to make to a single zone.
+type ZonePlan struct {
+	Name           string           `json:"name"`
+	CreateZone     bool             `json:"create_zone"`
+	ReplaceZone    bool             `json:"replace_zone"`
+	ZoneChanges    []string         `json:"zone_changes,omitempty"`
+	DeleteZone     bool             `json:"delete_zone"`
+	QuarantineZone bool             `json:"quarantine_zone"`
+	DNSSECChanges  []string         `json:"dnssec_changes,omitempty"`
+	Creates        []powerdns.RRset `json:"creates"`
+	Replaces       []powerdns.RRset `json:"replaces"`
+	Deletes        []powerdns.RRset `json:"deletes"`
+	Error          string           `json:"error,omitempty"`
+}
+
+// RecordConflict is a generated RRset that was dropped because another source has an RRset at the same name that can't
+// live alongside it. The kept and dropped sources are ownership strings, e.g. "manual:records/vip.nmn.example.com./A".
+type RecordConflict struct {
+	Name    string         `json:"name"`
+	Type    string         `json:"type"`
+	Reason  ConflictReason `json:"reason"`
+	Kept    string         `json:"kept"`
+	Dropped string         `json:"dropped"`
+}
+
+// Plan is the result of diffing the desired state computed from SLS and HSM against what is in PowerDNS. When the true
+// up is not a dry run it is also a record of what was done.
+type Plan struct {
+	DryRun    bool             `json:"dry_run"`
+	Zones     []*ZonePlan      `json:"zones"`
+	Conflicts []RecordConflict `json:"conflicts"`
+
+	zoneMap map[string]*ZonePlan
+
+	// desiredZones are all the zones that should exist according to SLS, anything else the manager created is orphaned.
+	desiredZones map[string]bool
+}
+
+func NewPlan(dryRun bool) *Plan {
+	return &Plan{
+		DryRun:       dryRun,
+		Zones:        []*ZonePlan{},
+		Conflicts:    []RecordConflict{},
+		zoneMap:      make(map[string]*ZonePlan),
+		desiredZones: make(map[string]bool),
+	}
+}
+
+// getZonePlan returns the plan for the given zone, creating it if this is the first time the zone has been seen.
+func (plan *Plan) getZonePlan(zoneName string) *ZonePlan {
+	zoneName = common.MakeDomainCanonical(zoneName)
+
+	zonePlan, found := plan.zoneMap[zoneName]
+	if !found {
+		zonePlan = &ZonePlan{
+			Name:     zoneName,
+			Creates:  []powerdns.RRset{},
+			Replaces: []powerdns.RRset{},
+			Deletes:  []powerdns.RRset{},
+		}
+		plan.zoneMap[zoneName] = zonePlan
+		plan.Zones = append(plan.Zones, zonePlan)
+	}
+
+	return zonePlan
+}
+
+// addDesiredZone records that the zone should exist.
+func (plan *Plan) addDesiredZone(zoneName string) {
+	plan.desiredZones[common.MakeDomainCanonical(zoneName)] = true
+}
+
+// isDesiredZone returns true if the zone was recorded as one that should exist.
+func (plan *Plan) isDesiredZone(zoneName string) bool {
+	return plan.desiredZones[common.MakeDomainCanonical(zoneName)]
+}
+
+// addConflict records that an RRset was dropped in favour of another.
+func (plan *Plan) addConflict(conflict RecordConflict) {
+	plan.Conflicts = append(plan.Conflicts, conflict)
+	logger.Warn("RRset conflicts with another RRset, dropping it.", zap.Any("conflict", conflict))
+}
+
+// hasChanges returns true if any zone has something to do or went wrong. Zones can be in the plan without either, e.g.,
+// every signed zone is looked at for DNSSEC key rollovers.
+func (plan *Plan) hasChanges() bool {
+	for _, zonePlan := range plan.Zones {
+		if zonePlan.CreateZone || zonePlan.ReplaceZone || len(zonePlan.ZoneChanges) > 0 || zonePlan.DeleteZone ||
+			zonePlan.QuarantineZone || len(zonePlan.DNSSECChanges) > 0 || zonePlan.HasRRsetChanges() ||
+			zonePlan.Error != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HasRRsetChanges returns true if there is at least one RRset to create, replace, or delete in this zone.
+func (zonePlan *ZonePlan) HasRRsetChanges() bool {
+	return len(zonePlan.Creates) > 0 || len(zonePlan.Replaces) > 0 || len(zonePlan.Deletes) > 0
+}
+
+// GetPatchRRsets returns the RRsets to send to PowerDNS for this zone. Deletes go first so that a name changing from
+// one type to another (e.g., CNAME to A) doesn't conflict with itself.
+func (zonePlan *ZonePlan) GetPatchRRsets() *powerdns.RRsets {
+	rrSets := &powerdns.RRsets{Sets: []powerdns.RRset{}}
+
+	rrSets.Sets = append(rrSets.Sets, zonePlan.Deletes...)
+	rrSets.Sets = append(rrSets.Sets, zonePlan.Creates...)
+	rrSets.Sets = append(rrSets.Sets, zonePlan.Replaces...)
+
+	return rrSets
+}
+
+// sortZones orders the zones and their RRsets by name so the output of a plan is stable between runs.
+func (plan *Plan) sortZones() {
+	sort.Slice(plan.Zones, func(i, j int) bool {
+		return plan.Zones[i].Name < plan.Zones[j].Name
+	})
+
+	for _, zonePlan := range plan.Zones {
+		for _, rrSets := range [][]powerdns.RRset{zonePlan.Creates, zonePlan.Replaces, zonePlan.Deletes} {
+			sort.Slice(rrSets, func(i, j int) bool {
+				if *rrSets[i].Name == *rrSets[j].Name {
+					return *rrSets[i].Type < *rrSets[j].Type
+				}
+				return *rrSets[i].Name < *rrSets[j].Name
+			})
+		}
+	}
+}
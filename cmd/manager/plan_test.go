@@ -0,0 +1,69 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package main
+
+import (
+	"testing"
+
+	"github.com/joeig/go-powerdns/v2"
+)
+
+func TestPlanHasChanges(t *testing.T) {
+	tests := []struct {
+		name   string
+		update func(zonePlan *ZonePlan)
+		want   bool
+	}{
+		{name: "only looked at", update: func(zonePlan *ZonePlan) {}},
+		{name: "create zone", update: func(zonePlan *ZonePlan) { zonePlan.CreateZone = true }, want: true},
+		{name: "zone changes", update: func(zonePlan *ZonePlan) {
+			zonePlan.ZoneChanges = append(zonePlan.ZoneChanges, "set kind to Master")
+		}, want: true},
+		{name: "DNSSEC changes", update: func(zonePlan *ZonePlan) {
+			zonePlan.DNSSECChanges = append(zonePlan.DNSSECChanges, "add zsk")
+		}, want: true},
+		{name: "RRset changes", update: func(zonePlan *ZonePlan) {
+			zonePlan.Creates = append(zonePlan.Creates, powerdns.RRset{})
+		}, want: true},
+		{name: "error", update: func(zonePlan *ZonePlan) { zonePlan.Error = "failed" }, want: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			plan := NewPlan(false)
+			plan.getZonePlan("example.com")
+			test.update(plan.getZonePlan("nmn.example.com"))
+
+			if got := plan.hasChanges(); got != test.want {
+				t.Errorf("hasChanges() = %t, want %t", got, test.want)
+			}
+		})
+	}
+
+	if NewPlan(false).hasChanges() {
+		t.Errorf("hasChanges() = true for an empty plan")
+	}
+}
@@ -0,0 +1,487 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Cray-HPE/cray-powerdns-manager/internal/common"
+	"github.com/joeig/go-powerdns/v2"
+	"go.uber.org/zap"
+)
+
+// Operator defined records are kept in the metadata of the base domain zone, one JSON encoded record per entry, so they
+// live and die with the PowerDNS database and are included in backups.
+const manualRecordsMetadataKind = "X-CRAY-POWERDNS-MANAGER-RECORDS"
+
+// manualRecordTypes are the types of RRset an operator can define.
+var manualRecordTypes = map[powerdns.RRType]bool{
+	powerdns.RRTypeA:     true,
+	powerdns.RRTypeAAAA:  true,
+	powerdns.RRTypeCNAME: true,
+	powerdns.RRTypePTR:   true,
+	powerdns.RRTypeSRV:   true,
+	powerdns.RRTypeTXT:   true,
+	powerdns.RRTypeMX:    true,
+	powerdns.RRTypeCAA:   true,
+}
+
+var (
+	errManualRecordInvalid  = errors.New("invalid record")
+	errManualRecordExists   = errors.New("record already exists")
+	errManualRecordNotFound = errors.New("record not found")
+)
+
+// ManualRecord is an RRset defined by an operator, either through the API or in the static records file. The records
+// are the content exactly as PowerDNS expects it.
+type ManualRecord struct {
+	Name    string          `json:"name" yaml:"name"`
+	Type    powerdns.RRType `json:"type" yaml:"type"`
+	TTL     uint32          `json:"ttl" yaml:"ttl"`
+	Records []string        `json:"records" yaml:"records"`
+}
+
+// manualRecordsMtx serializes changes to the records as every change rewrites all of them.
+var manualRecordsMtx sync.Mutex
+
+// isInZone returns true if the name is one of the zones or in one of them.
+func isInZone(name string, zoneNames []string) bool {
+	for _, zoneName := range zoneNames {
+		zoneName = common.MakeDomainCanonical(zoneName)
+		if name == zoneName || strings.HasSuffix(name, "."+zoneName) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// caaContentRegex splits CAA content into the flags, tag and value.
+var caaContentRegex = regexp.MustCompile(`^(\d+)\s+([a-zA-Z0-9]+)\s+(".*")$`)
+
+// isQuotedStrings returns true if the content is one or more quoted strings separated by spaces, the way PowerDNS wants
+// TXT and CAA values.
+func isQuotedStrings(content string) bool {
+	if content == "" {
+		return false
+	}
+
+	for content != "" {
+		if content[0] != '"' {
+			return false
+		}
+
+		end := -1
+		for i := 1; i < len(content); i++ {
+			if content[i] == '\\' {
+				i++
+				continue
+			}
+			if content[i] == '"' {
+				end = i
+				break
+			}
+		}
+		if end < 0 {
+			return false
+		}
+
+		rest := content[end+1:]
+		content = strings.TrimLeft(rest, " \t")
+		if content != "" && content == rest {
+			// Strings have to be separated.
+			return false
+		}
+	}
+
+	return true
+}
+
+// parseUint16Fields parses each field as an unsigned 16 bit number, the priorities, weights and ports of SRV and MX.
+func parseUint16Fields(fields []string) bool {
+	for _, field := range fields {
+		if _, err := strconv.ParseUint(field, 10, 16); err != nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+// normalizeManualRecordContent makes sure the content is valid for the type, making any names in it canonical.
+func normalizeManualRecordContent(rrType powerdns.RRType, content string) (string, error) {
+	switch rrType {
+	case powerdns.RRTypeA:
+		if ip := net.ParseIP(content); ip == nil || ip.To4() == nil {
+			return "", fmt.Errorf("%w: %s is not an IPv4 address", errManualRecordInvalid, content)
+		}
+	case powerdns.RRTypeAAAA:
+		if ip := net.ParseIP(content); ip == nil || ip.To4() != nil {
+			return "", fmt.Errorf("%w: %s is not an IPv6 address", errManualRecordInvalid, content)
+		}
+	case powerdns.RRTypeCNAME, powerdns.RRTypePTR:
+		content = common.MakeDomainCanonical(strings.ToLower(content))
+	case powerdns.RRTypeTXT:
+		if !isQuotedStrings(content) {
+			return "", fmt.Errorf("%w: TXT record %s must be quoted", errManualRecordInvalid, content)
+		}
+	case powerdns.RRTypeSRV:
+		fields := strings.Fields(content)
+		if len(fields) != 4 || !parseUint16Fields(fields[:3]) {
+			return "", fmt.Errorf("%w: SRV record %s is not in the <priority> <weight> <port> <target> format",
+				errManualRecordInvalid, content)
+		}
+		fields[3] = common.MakeDomainCanonical(strings.ToLower(fields[3]))
+		content = strings.Join(fields, " ")
+	case powerdns.RRTypeMX:
+		fields := strings.Fields(content)
+		if len(fields) != 2 || !parseUint16Fields(fields[:1]) {
+			return "", fmt.Errorf("%w: MX record %s is not in the <preference> <host> format", errManualRecordInvalid,
+				content)
+		}
+		fields[1] = common.MakeDomainCanonical(strings.ToLower(fields[1]))
+		content = strings.Join(fields, " ")
+	case powerdns.RRTypeCAA:
+		matches := caaContentRegex.FindStringSubmatch(content)
+		if matches == nil || !isQuotedStrings(matches[3]) {
+			return "", fmt.Errorf("%w: CAA record %s is not in the <flags> <tag> \"<value>\" format",
+				errManualRecordInvalid, content)
+		}
+		if _, err := strconv.ParseUint(matches[1], 10, 8); err != nil {
+			return "", fmt.Errorf("%w: CAA record %s has invalid flags", errManualRecordInvalid, content)
+		}
+		content = fmt.Sprintf("%s %s %s", matches[1], strings.ToLower(matches[2]), matches[3])
+	}
+
+	return content, nil
+}
+
+// normalizeManualRecord makes the name canonical, fills in the default TTL, and makes sure the record is something
+// PowerDNS will accept and the manager can place in one of the zones.
+func normalizeManualRecord(record *ManualRecord, zoneNames []string) error {
+	record.Name = strings.ToLower(strings.TrimSpace(record.Name))
+	record.Type = powerdns.RRType(strings.ToUpper(string(record.Type)))
+	if record.TTL == 0 {
+		record.TTL = recordTTLs.Get(record.Type, "")
+	}
+
+	if record.Name == "" {
+		return fmt.Errorf("%w: name is required", errManualRecordInvalid)
+	}
+	record.Name = common.MakeDomainCanonical(record.Name)
+
+	if !isInZone(record.Name, zoneNames) {
+		return fmt.Errorf("%w: %s is not in a zone the manager manages", errManualRecordInvalid, record.Name)
+	}
+
+	if !manualRecordTypes[record.Type] {
+		return fmt.Errorf("%w: type %s is not supported", errManualRecordInvalid, record.Type)
+	}
+
+	if len(record.Records) == 0 {
+		return fmt.Errorf("%w: at least one record is required", errManualRecordInvalid)
+	}
+	if record.Type == powerdns.RRTypeCNAME && len(record.Records) > 1 {
+		return fmt.Errorf("%w: a CNAME can only have one record", errManualRecordInvalid)
+	}
+
+	for i, content := range record.Records {
+		content = strings.TrimSpace(content)
+		if content == "" {
+			return fmt.Errorf("%w: records can't be empty", errManualRecordInvalid)
+		}
+
+		content, err := normalizeManualRecordContent(record.Type, content)
+		if err != nil {
+			return err
+		}
+		record.Records[i] = content
+	}
+
+	return nil
+}
+
+// checkManualRecordZones makes sure every record is in one of the zones, it returns the first that isn't.
+func checkManualRecordZones(records []ManualRecord, zones common.PowerDNSZones) error {
+	var zoneNames []string
+	for _, zone := range zones {
+		zoneNames = append(zoneNames, *zone.Name)
+	}
+
+	for _, record := range records {
+		if !isInZone(record.Name, zoneNames) {
+			return fmt.Errorf("%w: %s is not in a zone the manager manages", errManualRecordInvalid, record.Name)
+		}
+	}
+
+	return nil
+}
+
+// getManagedZoneNames returns the zones the manager manages in PowerDNS, quarantined zones aren't among them.
+func getManagedZoneNames() (zoneNames []string, err error) {
+	zones, err := pdns.Zones.List()
+	recordPowerDNSResult(err)
+	if err != nil {
+		err = fmt.Errorf("failed to list zones: %w", err)
+		return
+	}
+
+	for _, zone := range zones {
+		if zone.Name != nil && zone.Account != nil && *zone.Account == common.ManagerAccount {
+			zoneNames = append(zoneNames, *zone.Name)
+		}
+	}
+
+	return
+}
+
+// toRRset returns the RRset for the record, owned by the manager with the given ownership so it is removed once the
+// record is gone.
+func (record ManualRecord) toRRset(ownership common.RRsetOwnership) powerdns.RRset {
+	rrSet := powerdns.RRset{
+		Name:       powerdns.String(record.Name),
+		Type:       powerdns.RRTypePtr(record.Type),
+		TTL:        powerdns.Uint32(record.TTL),
+		ChangeType: powerdns.ChangeTypePtr(powerdns.ChangeTypeReplace),
+		Comments:   common.GetOwnershipComments(ownership),
+	}
+	for _, content := range record.Records {
+		rrSet.Records = append(rrSet.Records, powerdns.Record{
+			Content:  powerdns.String(content),
+			Disabled: powerdns.Bool(false),
+		})
+	}
+
+	return rrSet
+}
+
+// getManualRRSets returns the RRsets for the operator defined records.
+func getManualRRSets(records []ManualRecord) (rrSets []powerdns.RRset) {
+	for _, record := range records {
+		rrSets = append(rrSets, record.toRRset(common.GetManualRecordOwnership(record.Name, string(record.Type))))
+	}
+
+	return
+}
+
+// getManualRecords returns every operator defined record. Until the base domain zone exists there can't be any.
+func getManualRecords() (records []ManualRecord, err error) {
+	records = []ManualRecord{}
+
+	var metadata zoneMetadata
+	err = doPowerDNSRequest(http.MethodGet, fmt.Sprintf("zones/%s/metadata/%s",
+		common.MakeDomainCanonical(*baseDomain), manualRecordsMetadataKind), nil, &metadata)
+	if err != nil {
+		pdnsErr, ok := err.(*powerdns.Error)
+		if ok && pdnsErr.StatusCode == http.StatusNotFound {
+			err = nil
+		} else {
+			err = fmt.Errorf("failed to get records: %w", err)
+		}
+		return
+	}
+
+	for _, entry := range metadata.Metadata {
+		var record ManualRecord
+		if unmarshalErr := json.Unmarshal([]byte(entry), &record); unmarshalErr != nil {
+			logger.Warn("Ignoring malformed record", zap.String("entry", entry), zap.Error(unmarshalErr))
+			continue
+		}
+		records = append(records, record)
+	}
+
+	return
+}
+
+// setManualRecords saves the records, replacing all those there were before.
+func setManualRecords(records []ManualRecord) error {
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].Name == records[j].Name {
+			return records[i].Type < records[j].Type
+		}
+		return records[i].Name < records[j].Name
+	})
+
+	metadata := zoneMetadata{
+		Kind:     manualRecordsMetadataKind,
+		Metadata: []string{},
+	}
+	for _, record := range records {
+		entry, err := json.Marshal(record)
+		if err != nil {
+			return fmt.Errorf("failed to marshal record: %w", err)
+		}
+		metadata.Metadata = append(metadata.Metadata, string(entry))
+	}
+
+	baseDomainName := common.MakeDomainCanonical(*baseDomain)
+	err := doPowerDNSRequest(http.MethodPut, fmt.Sprintf("zones/%s/metadata/%s", baseDomainName,
+		manualRecordsMetadataKind), metadata, nil)
+	if err != nil {
+		pdnsErr, ok := err.(*powerdns.Error)
+		if ok && pdnsErr.StatusCode == http.StatusNotFound {
+			return fmt.Errorf("failed to save records, zone %s doesn't exist yet: %w", baseDomainName, err)
+		}
+		return fmt.Errorf("failed to save records: %w", err)
+	}
+
+	return nil
+}
+
+// findManualRecord returns the index of the record with the given name and type or -1 if there isn't one.
+func findManualRecord(records []ManualRecord, name string, rrType powerdns.RRType) int {
+	for i, record := range records {
+		if record.Name == name && record.Type == rrType {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// putManualRecord adds the record or, if replace is set, replaces the existing record with the same name and type.
+func putManualRecord(record ManualRecord, replace bool) (err error) {
+	// Only the zones that actually exist can hold the record, the reverse zones in particular depend on SLS.
+	zoneNames, err := getManagedZoneNames()
+	if err != nil {
+		return
+	}
+	err = normalizeManualRecord(&record, zoneNames)
+	if err != nil {
+		return
+	}
+
+	manualRecordsMtx.Lock()
+	defer manualRecordsMtx.Unlock()
+
+	records, err := getManualRecords()
+	if err != nil {
+		return
+	}
+
+	for _, existingRecord := range records {
+		if existingRecord.Name == record.Name && existingRecord.Type != record.Type &&
+			(existingRecord.Type == powerdns.RRTypeCNAME || record.Type == powerdns.RRTypeCNAME) {
+			return fmt.Errorf("%w: a CNAME can't share its name with a record of another type",
+				errManualRecordInvalid)
+		}
+	}
+
+	i := findManualRecord(records, record.Name, record.Type)
+	switch {
+	case i >= 0 && !replace:
+		return fmt.Errorf("%w: %s %s", errManualRecordExists, record.Name, record.Type)
+	case i >= 0:
+		records[i] = record
+	default:
+		records = append(records, record)
+	}
+
+	return setManualRecords(records)
+}
+
+// deleteManualRecord deletes the record with the given name and type.
+func deleteManualRecord(name string, rrType powerdns.RRType) (err error) {
+	manualRecordsMtx.Lock()
+	defer manualRecordsMtx.Unlock()
+
+	records, err := getManualRecords()
+	if err != nil {
+		return
+	}
+
+	i := findManualRecord(records, name, rrType)
+	if i < 0 {
+		return fmt.Errorf("%w: %s %s", errManualRecordNotFound, name, rrType)
+	}
+
+	return setManualRecords(append(records[:i], records[i+1:]...))
+}
+
+// queueRecordsTrueUp makes sure a true up is on its way so record changes show up in DNS without waiting for the loop.
+func queueRecordsTrueUp() {
+	trueUpMtx.Lock()
+	defer trueUpMtx.Unlock()
+
+	if len(trueUpRunNow) == 0 {
+		trueUpRunNow <- newJob(JobTriggerRecords)
+	} else {
+		logger.Debug("True up already queued, not queueing another for the record change.")
+	}
+}
+
+// mergeOverrideRRSets adds operator defined RRsets to the generated RRsets. The operator always wins, any generated
+// RRset with the same name and type, or that can't share its name with an override because one of them is a CNAME, is
+// dropped and recorded in the plan as a conflict.
+func mergeOverrideRRSets(rrSets []powerdns.RRset, overrideRRSets []powerdns.RRset,
+	plan *Plan) (mergedRRSets []powerdns.RRset) {
+	if len(overrideRRSets) == 0 {
+		return rrSets
+	}
+
+	overrideTypes := make(map[string]map[powerdns.RRType]string)
+	for _, overrideRRSet := range overrideRRSets {
+		if overrideTypes[*overrideRRSet.Name] == nil {
+			overrideTypes[*overrideRRSet.Name] = make(map[powerdns.RRType]string)
+		}
+		ownership, _ := common.GetRRsetOwnership(overrideRRSet)
+		overrideTypes[*overrideRRSet.Name][*overrideRRSet.Type] = ownership.String()
+	}
+
+rrSets:
+	for _, rrSet := range rrSets {
+		for overrideType, overrideOwnership := range overrideTypes[*rrSet.Name] {
+			if overrideType != *rrSet.Type &&
+				overrideType != powerdns.RRTypeCNAME && *rrSet.Type != powerdns.RRTypeCNAME {
+				continue
+			}
+
+			ownership, _ := common.GetRRsetOwnership(rrSet)
+			plan.addConflict(RecordConflict{
+				Name:    *rrSet.Name,
+				Type:    string(*rrSet.Type),
+				Reason:  ConflictReasonOverride,
+				Kept:    overrideOwnership,
+				Dropped: ownership.String(),
+			})
+
+			continue rrSets
+		}
+
+		mergedRRSets = append(mergedRRSets, rrSet)
+	}
+
+	return append(mergedRRSets, overrideRRSets...)
+}
@@ -0,0 +1,96 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/joeig/go-powerdns/v2"
+)
+
+func TestNormalizeManualRecord(t *testing.T) {
+	zoneNames := []string{"example.com.", "nmn.example.com.", "252.10.in-addr.arpa."}
+
+	tests := []struct {
+		name        string
+		record      ManualRecord
+		wantRecords []string
+		wantErr     bool
+	}{
+		{name: "A", record: ManualRecord{Name: "VIP.nmn.example.com", Type: "a", Records: []string{"10.252.1.100"}},
+			wantRecords: []string{"10.252.1.100"}},
+		{name: "A that isn't IPv4", record: ManualRecord{Name: "vip.example.com", Type: powerdns.RRTypeA,
+			Records: []string{"fd00::1"}}, wantErr: true},
+		{name: "PTR in a reverse zone", record: ManualRecord{Name: "100.1.252.10.in-addr.arpa",
+			Type: powerdns.RRTypePTR, Records: []string{"VIP.nmn.example.com"}},
+			wantRecords: []string{"vip.nmn.example.com."}},
+		{name: "PTR outside the reverse zones", record: ManualRecord{Name: "1.1.168.192.in-addr.arpa",
+			Type: powerdns.RRTypePTR, Records: []string{"vip.nmn.example.com."}}, wantErr: true},
+		{name: "outside every zone", record: ManualRecord{Name: "www.elsewhere.example", Type: powerdns.RRTypeA,
+			Records: []string{"10.1.1.1"}}, wantErr: true},
+		{name: "TXT", record: ManualRecord{Name: "example.com", Type: powerdns.RRTypeTXT,
+			Records: []string{`"v=spf1 -all"`, `"part one" "part \"two\""`}},
+			wantRecords: []string{`"v=spf1 -all"`, `"part one" "part \"two\""`}},
+		{name: "TXT that isn't quoted", record: ManualRecord{Name: "example.com", Type: powerdns.RRTypeTXT,
+			Records: []string{"v=spf1 -all"}}, wantErr: true},
+		{name: "TXT with an unterminated quote", record: ManualRecord{Name: "example.com", Type: powerdns.RRTypeTXT,
+			Records: []string{`"v=spf1 -all\"`}}, wantErr: true},
+		{name: "SRV", record: ManualRecord{Name: "_ldap._tcp.example.com", Type: powerdns.RRTypeSRV,
+			Records: []string{"0  5 389 LDAP.example.com"}}, wantRecords: []string{"0 5 389 ldap.example.com."}},
+		{name: "SRV without a weight", record: ManualRecord{Name: "_ldap._tcp.example.com", Type: powerdns.RRTypeSRV,
+			Records: []string{"0 389 ldap.example.com."}}, wantErr: true},
+		{name: "SRV with a port that is too big", record: ManualRecord{Name: "_ldap._tcp.example.com",
+			Type: powerdns.RRTypeSRV, Records: []string{"0 5 65536 ldap.example.com."}}, wantErr: true},
+		{name: "MX", record: ManualRecord{Name: "example.com", Type: powerdns.RRTypeMX,
+			Records: []string{"10 mail.example.com"}}, wantRecords: []string{"10 mail.example.com."}},
+		{name: "MX without a preference", record: ManualRecord{Name: "example.com", Type: powerdns.RRTypeMX,
+			Records: []string{"mail.example.com."}}, wantErr: true},
+		{name: "CAA", record: ManualRecord{Name: "example.com", Type: powerdns.RRTypeCAA,
+			Records: []string{`0 Issue "letsencrypt.org"`}}, wantRecords: []string{`0 issue "letsencrypt.org"`}},
+		{name: "CAA with an unquoted value", record: ManualRecord{Name: "example.com", Type: powerdns.RRTypeCAA,
+			Records: []string{"0 issue letsencrypt.org"}}, wantErr: true},
+		{name: "CAA with invalid flags", record: ManualRecord{Name: "example.com", Type: powerdns.RRTypeCAA,
+			Records: []string{`256 issue "letsencrypt.org"`}}, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			record := test.record
+			err := normalizeManualRecord(&record, zoneNames)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("normalizeManualRecord() error = %v, wantErr %v", err, test.wantErr)
+			}
+			if test.wantErr {
+				return
+			}
+
+			if !reflect.DeepEqual(record.Records, test.wantRecords) {
+				t.Errorf("normalizeManualRecord() records = %v, want %v", record.Records, test.wantRecords)
+			}
+		})
+	}
+}
@@ -0,0 +1,322 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Cray-HPE/cray-powerdns-manager/internal/common"
+	"github.com/Cray-HPE/cray-powerdns-manager/internal/sls"
+	base "github.com/Cray-HPE/hms-base"
+	"github.com/Cray-HPE/hms-smd/pkg/sm"
+	"github.com/joeig/go-powerdns/v2"
+	"go.uber.org/zap"
+)
+
+const (
+	RenderFormatBIND = "bind"
+	RenderFormatJSON = "json"
+)
+
+// RenderedZone is a zone as it would be created by the manager.
+type RenderedZone struct {
+	Name   string           `json:"name"`
+	RRsets []powerdns.RRset `json:"rrsets"`
+}
+
+// readJSONFile unmarshals the JSON file at path into v.
+func readJSONFile(path string, v interface{}) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	err = json.Unmarshal(data, v)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// renderZones builds every zone and RRset from SLS and HSM dumps on disk, without talking to PowerDNS, SLS, or HSM.
+// The HSM dumps and static records file are optional, without them there are simply no records from them.
+//
+// The ownership comments are left out as the last seen time would make the output different every time.
+func renderZones(slsFile string, ethernetInterfacesFile string, stateFile string,
+	staticRecordsFile string) (renderedZones []RenderedZone, err error) {
+	networks, hardware, err := sls.ReadDumpFile(slsFile)
+	if err != nil {
+		return
+	}
+	networks, err = sls.CombineNetworks(networks, ignoreSLSNetworksArray)
+	if err != nil {
+		err = fmt.Errorf("failed to combine networks: %w", err)
+		return
+	}
+
+	var ethernetInterfaces []sm.CompEthInterfaceV2
+	if ethernetInterfacesFile != "" {
+		err = readJSONFile(ethernetInterfacesFile, &ethernetInterfaces)
+		if err != nil {
+			return
+		}
+	}
+
+	var stateComponents base.ComponentArray
+	if stateFile != "" {
+		err = readJSONFile(stateFile, &stateComponents)
+		if err != nil {
+			return
+		}
+	}
+
+	staticRecords, err := loadStaticRecords(staticRecordsFile)
+	if err != nil {
+		return
+	}
+
+	ipv6Networks := getIPv6Networks(networks, hardware)
+
+	reverseZoneSpecs, err := getReverseZoneSpecs(networks, ipv6Networks, masterNameserver, slaveNameservers)
+	if err != nil {
+		err = fmt.Errorf("failed to get reverse zones: %w", err)
+		return
+	}
+
+	// The zones start out as PowerDNS would create them, the RRsets they were created with plus the apex NS RRset.
+	var zones common.PowerDNSZones
+	zoneRRSets := make(map[string]map[common.RRsetKey]powerdns.RRset)
+	addZone := func(spec zoneSpec) *powerdns.Zone {
+		zoneName := common.MakeDomainCanonical(spec.name)
+		zone := &powerdns.Zone{Name: powerdns.String(zoneName)}
+		zones = append(zones, zone)
+
+		nsRRSet := powerdns.RRset{
+			Name: powerdns.String(zoneName),
+			Type: powerdns.RRTypePtr(powerdns.RRTypeNS),
+			TTL:  powerdns.Uint32(recordTTLs.Get(powerdns.RRTypeNS, "")),
+		}
+		for _, nameserverFQDN := range spec.nameserverFQDNs {
+			nsRRSet.Records = append(nsRRSet.Records, powerdns.Record{
+				Content:  powerdns.String(common.MakeDomainCanonical(nameserverFQDN)),
+				Disabled: powerdns.Bool(false),
+			})
+		}
+
+		zoneRRSets[zoneName] = make(map[common.RRsetKey]powerdns.RRset)
+		for _, rrSet := range append(spec.rrSets, nsRRSet) {
+			zoneRRSets[zoneName][common.GetRRsetKey(rrSet)] = rrSet
+		}
+
+		return zone
+	}
+
+	for _, spec := range getMasterZoneSpecs(*baseDomain, networks, staticRecords.Zones, masterNameserver,
+		slaveNameservers) {
+		addZone(spec)
+	}
+	var reverseZones []*powerdns.Zone
+	for _, spec := range reverseZoneSpecs {
+		reverseZones = append(reverseZones, addZone(spec))
+	}
+	err = staticRecords.checkZones(zones)
+	if err != nil {
+		return
+	}
+
+	finalRRSet, desiredStateComplete := buildDesiredRRSets(networks, hardware, ipv6Networks, ethernetInterfaces,
+		stateComponents, reverseZones)
+	if !desiredStateComplete {
+		err = fmt.Errorf("failed to build every RRset, see the log for details")
+		return
+	}
+	plan := NewPlan(true)
+	finalRRSet = resolveConflicts(finalRRSet, plan)
+	finalRRSet = mergeOverrideRRSets(finalRRSet, staticRecords.getRRSets(), plan)
+
+	for _, desiredRRset := range getDesiredRRSetMap(finalRRSet) {
+		zoneName := common.GetZoneForRRSet(desiredRRset, zones)
+		if zoneName == nil {
+			logger.Error("Desired RRSet did not match any master zones!", zap.Any("desiredRRset", desiredRRset))
+			continue
+		}
+
+		zoneRRSets[*zoneName][common.GetRRsetKey(desiredRRset)] = desiredRRset
+	}
+
+	for _, zone := range zones {
+		renderedZone := RenderedZone{
+			Name:   *zone.Name,
+			RRsets: []powerdns.RRset{},
+		}
+
+		for _, rrSet := range zoneRRSets[*zone.Name] {
+			records := append([]powerdns.Record{}, rrSet.Records...)
+			sort.Slice(records, func(i, j int) bool {
+				return *records[i].Content < *records[j].Content
+			})
+
+			renderedZone.RRsets = append(renderedZone.RRsets, powerdns.RRset{
+				Name:    rrSet.Name,
+				Type:    rrSet.Type,
+				TTL:     rrSet.TTL,
+				Records: records,
+			})
+		}
+
+		// SOA first as BIND expects, then by name and type.
+		sort.Slice(renderedZone.RRsets, func(i, j int) bool {
+			a, b := renderedZone.RRsets[i], renderedZone.RRsets[j]
+			if (*a.Type == powerdns.RRTypeSOA) != (*b.Type == powerdns.RRTypeSOA) {
+				return *a.Type == powerdns.RRTypeSOA
+			}
+			if *a.Name == *b.Name {
+				return *a.Type < *b.Type
+			}
+			return *a.Name < *b.Name
+		})
+
+		renderedZones = append(renderedZones, renderedZone)
+	}
+
+	sort.Slice(renderedZones, func(i, j int) bool {
+		return renderedZones[i].Name < renderedZones[j].Name
+	})
+
+	return
+}
+
+// getZoneFileName returns a file name for the zone with the given extension. Classless zone names use a dash rather than
+// the RFC 2317 slash, see common.GetClasslessReverseZoneName, so every zone name is already a valid file name.
+func getZoneFileName(zoneName string, extension string) string {
+	return fmt.Sprintf("%s.%s", strings.TrimSuffix(zoneName, "."), extension)
+}
+
+// writeBINDZone writes the zone in the BIND zone file format with every name fully qualified.
+func writeBINDZone(w io.Writer, zone RenderedZone) (err error) {
+	_, err = fmt.Fprintf(w, "$ORIGIN %s\n", zone.Name)
+	if err != nil {
+		return
+	}
+
+	for _, rrSet := range zone.RRsets {
+		for _, record := range rrSet.Records {
+			if record.Disabled != nil && *record.Disabled {
+				continue
+			}
+
+			_, err = fmt.Fprintf(w, "%s\t%d\tIN\t%s\t%s\n", *rrSet.Name, *rrSet.TTL, *rrSet.Type, *record.Content)
+			if err != nil {
+				return
+			}
+		}
+	}
+
+	return
+}
+
+// writeRenderedZones writes the zones in the given format. With an output directory BIND zones each get their own
+// <zone>.zone file and JSON goes to zones.json, otherwise everything goes to stdout.
+func writeRenderedZones(renderedZones []RenderedZone, format string, outputDir string) (err error) {
+	if format == RenderFormatJSON {
+		var data []byte
+		data, err = json.MarshalIndent(renderedZones, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal zones: %w", err)
+		}
+		data = append(data, '\n')
+
+		if outputDir == "" {
+			_, err = os.Stdout.Write(data)
+			return
+		}
+
+		return ioutil.WriteFile(filepath.Join(outputDir, "zones.json"), data, 0644)
+	}
+
+	for i, zone := range renderedZones {
+		if outputDir == "" {
+			if i > 0 {
+				fmt.Println()
+			}
+			err = writeBINDZone(os.Stdout, zone)
+			if err != nil {
+				return
+			}
+			continue
+		}
+
+		var zoneFile *os.File
+		zoneFile, err = os.Create(filepath.Join(outputDir, getZoneFileName(zone.Name, "zone")))
+		if err != nil {
+			return
+		}
+		err = writeBINDZone(zoneFile, zone)
+		closeErr := zoneFile.Close()
+		if err != nil {
+			return
+		}
+		if closeErr != nil {
+			return closeErr
+		}
+	}
+
+	return
+}
+
+// runRender renders the zones from the files given on the command line and writes them out.
+func runRender() (err error) {
+	switch *renderFormat {
+	case RenderFormatBIND, RenderFormatJSON:
+	default:
+		return fmt.Errorf("invalid render format %s", *renderFormat)
+	}
+
+	if *renderOutputDir != "" {
+		err = os.MkdirAll(*renderOutputDir, 0755)
+		if err != nil {
+			return fmt.Errorf("failed to create output directory: %w", err)
+		}
+	}
+
+	renderedZones, err := renderZones(*renderSLSFile, *renderHSMEthernetInterfacesFile, *renderHSMStateFile,
+		*staticRecordsFile)
+	if err != nil {
+		return
+	}
+
+	logger.Info("Rendered zones", zap.Int("numZones", len(renderedZones)))
+
+	return writeRenderedZones(renderedZones, *renderFormat, *renderOutputDir)
+}
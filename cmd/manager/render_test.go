@@ -0,0 +1,142 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const testSLSDump = `{
+  "Networks": {
+    "NMN": {
+      "Name": "NMN",
+      "IPRanges": ["10.252.0.0/17"],
+      "Type": "ethernet",
+      "ExtraProperties": {
+        "CIDR": "10.252.0.0/17",
+        "Subnets": [{
+          "Name": "bootstrap_dhcp",
+          "CIDR": "10.252.0.0/24",
+          "IPReservations": [{
+            "Name": "ncn-m001",
+            "IPAddress": "10.252.1.4",
+            "Comment": "x3000c0s1b0n0",
+            "Aliases": ["ncn-m001-nmn"]
+          }]
+        }]
+      }
+    }
+  },
+  "Hardware": {
+    "x3000c0s1b0n0": {
+      "Xname": "x3000c0s1b0n0",
+      "Type": "comptype_node",
+      "TypeString": "Node",
+      "Class": "River",
+      "ExtraProperties": {"NID": 100001, "Role": "Management", "Aliases": ["ncn-m001"]}
+    }
+  }
+}`
+
+func TestRenderZones(t *testing.T) {
+	dir := t.TempDir()
+	slsFile := filepath.Join(dir, "sls.json")
+	if err := os.WriteFile(slsFile, []byte(testSLSDump), 0644); err != nil {
+		t.Fatal(err)
+	}
+	staticRecordsFile := filepath.Join(dir, "static.yaml")
+	staticRecords := "records: [{name: www.example.com, type: A, records: [\"10.1.1.1\"]}]\n"
+	if err := os.WriteFile(staticRecordsFile, []byte(staticRecords), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	renderedZones, err := renderZones(slsFile, "", "", staticRecordsFile)
+	if err != nil {
+		t.Fatalf("renderZones() error = %v", err)
+	}
+
+	want := map[string]map[string][]string{
+		"252.10.in-addr.arpa.": {
+			"252.10.in-addr.arpa. NS":      {"ns1.example.com."},
+			"4.1.252.10.in-addr.arpa. PTR": {"ncn-m001.nmn.example.com."},
+		},
+		"example.com.": {
+			"example.com. NS":     {"ns1.example.com."},
+			"nmn.example.com. NS": {"ns1.example.com."},
+			"ns1.example.com. A":  {"10.92.100.71"},
+			"www.example.com. A":  {"10.1.1.1"},
+		},
+		"nmn.example.com.": {
+			"nmn.example.com. NS":                 {"ns1.example.com."},
+			"x3000c0s1b0n0.nmn.example.com. A":    {"10.252.1.4"},
+			"ncn-m001.nmn.example.com. CNAME":     {"x3000c0s1b0n0.nmn.example.com."},
+			"ncn-m001-nmn.nmn.example.com. CNAME": {"x3000c0s1b0n0.nmn.example.com."},
+		},
+	}
+
+	// The SOA serial is the date it was rendered on so only check that each zone has one.
+	got := make(map[string]map[string][]string)
+	for _, renderedZone := range renderedZones {
+		got[renderedZone.Name] = getTestRRsetContents(renderedZone.RRsets)
+		soaKey := renderedZone.Name + " SOA"
+		if len(got[renderedZone.Name][soaKey]) != 1 {
+			t.Errorf("renderZones() zone %s has no SOA", renderedZone.Name)
+		}
+		delete(got[renderedZone.Name], soaKey)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("renderZones() = %v, want %v", got, want)
+	}
+
+	var zoneNames []string
+	for _, renderedZone := range renderedZones {
+		zoneNames = append(zoneNames, renderedZone.Name)
+	}
+	wantZoneNames := []string{"252.10.in-addr.arpa.", "example.com.", "nmn.example.com."}
+	if !reflect.DeepEqual(zoneNames, wantZoneNames) {
+		t.Errorf("renderZones() zone order = %v, want %v", zoneNames, wantZoneNames)
+	}
+}
+
+func TestGetZoneFileName(t *testing.T) {
+	tests := []struct {
+		zoneName string
+		want     string
+	}{
+		{"example.com.", "example.com.db"},
+		{"example.com", "example.com.db"},
+		{"0-26.1.168.192.in-addr.arpa.", "0-26.1.168.192.in-addr.arpa.db"},
+	}
+
+	for _, test := range tests {
+		if got := getZoneFileName(test.zoneName, "db"); got != test.want {
+			t.Errorf("getZoneFileName(%q) = %q, want %q", test.zoneName, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package main
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/Cray-HPE/cray-powerdns-manager/internal/common"
+	sls_common "github.com/Cray-HPE/hms-sls/pkg/sls-common"
+	"github.com/joeig/go-powerdns/v2"
+)
+
+// isClassless returns true if RFC 2317 reverse zones are enabled and the network is small enough to need one.
+func isClassless(cidr *net.IPNet) bool {
+	return *rfc2317ReverseZones && cidr != nil && common.IsClasslessCIDR(cidr)
+}
+
+// getReverseZoneName returns the name of the reverse zone for the network, the RFC 2317 classless zone if enabled.
+func getReverseZoneName(cidr *net.IPNet) string {
+	if isClassless(cidr) {
+		return common.GetClasslessReverseZoneName(cidr)
+	}
+
+	return common.GetReverseZoneName(cidr)
+}
+
+// getReverseNameForIP returns the name of the PTR for an address in the network, inside the RFC 2317 classless zone if
+// enabled.
+func getReverseNameForIP(ip net.IP, cidr *net.IPNet) string {
+	if isClassless(cidr) && cidr.Contains(ip) {
+		return common.GetClasslessReverseName(ip, cidr)
+	}
+
+	return common.GetReverseNameForIP(ip)
+}
+
+// getClasslessParentCIDR returns the /24 that contains a classless network, the parent zone is named for this.
+func getClasslessParentCIDR(cidr *net.IPNet) *net.IPNet {
+	mask := net.CIDRMask(24, 32)
+
+	return &net.IPNet{
+		IP:   cidr.IP.To4().Mask(mask),
+		Mask: mask,
+	}
+}
+
+// buildClasslessDelegationRRSets builds the records the /24 parent reverse zone needs to delegate to each RFC 2317
+// classless zone. That is NS records for the classless zone and a CNAME from every address in the parent to its PTR in
+// the classless zone.
+func buildClasslessDelegationRRSets(networks []sls_common.Network) (delegationRRSets []powerdns.RRset, err error) {
+	if !*rfc2317ReverseZones {
+		return
+	}
+
+	for _, network := range networks {
+		for _, ipRange := range network.IPRanges {
+			var cidr *net.IPNet
+			_, cidr, err = net.ParseCIDR(ipRange)
+			if err != nil {
+				return
+			}
+			if !isClassless(cidr) {
+				continue
+			}
+
+			ownership := common.GetSLSNetworkOwnership(network.Name)
+			classlessZoneName := common.GetClasslessReverseZoneName(cidr)
+			parentZoneName := common.MakeDomainCanonical(common.GetReverseZoneName(getClasslessParentCIDR(cidr)))
+
+			nsRRset := powerdns.RRset{
+				Name:       powerdns.String(common.MakeDomainCanonical(classlessZoneName)),
+				Type:       powerdns.RRTypePtr(powerdns.RRTypeNS),
+				TTL:        powerdns.Uint32(recordTTLs.Get(powerdns.RRTypeNS, "")),
+				ChangeType: powerdns.ChangeTypePtr(powerdns.ChangeTypeReplace),
+				Comments:   common.GetOwnershipComments(ownership),
+			}
+			nameserverFQDNs := getReverseZoneNameserverFQDNs(classlessZoneName, masterNameserver, slaveNameservers)
+			for _, nameserverFQDN := range nameserverFQDNs {
+				nsRRset.Records = append(nsRRset.Records, powerdns.Record{
+					Content:  powerdns.String(nameserverFQDN),
+					Disabled: powerdns.Bool(false),
+				})
+			}
+			delegationRRSets = append(delegationRRSets, nsRRset)
+
+			prefix, _ := cidr.Mask.Size()
+			first := int(cidr.IP.To4()[3])
+			last := first + (1 << (32 - prefix)) - 1
+			for lastOctet := first; lastOctet <= last; lastOctet++ {
+				delegationRRSets = append(delegationRRSets, powerdns.RRset{
+					Name:       powerdns.String(fmt.Sprintf("%d.%s", lastOctet, parentZoneName)),
+					Type:       powerdns.RRTypePtr(powerdns.RRTypeCNAME),
+					TTL:        powerdns.Uint32(recordTTLs.Get(powerdns.RRTypeCNAME, network.Name)),
+					ChangeType: powerdns.ChangeTypePtr(powerdns.ChangeTypeReplace),
+					Comments:   common.GetOwnershipComments(ownership),
+					Records: []powerdns.Record{
+						{
+							Content:  powerdns.String(fmt.Sprintf("%d.%s.", lastOctet, classlessZoneName)),
+							Disabled: powerdns.Bool(false),
+						},
+					},
+				})
+			}
+		}
+	}
+
+	return
+}
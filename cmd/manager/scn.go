@@ -0,0 +1,106 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package main
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const scnSubscriber = "cray-powerdns-manager"
+
+var (
+	// pendingSCNComponents are the components HSM has told us about that haven't been trued up yet.
+	pendingSCNComponents = make(map[string]bool)
+	scnMtx               sync.Mutex
+)
+
+// queueSCNComponents remembers the components from a state change notification and wakes up the true up loop. If the
+// loop is busy the components pile up and are all handled by the next run.
+func queueSCNComponents(componentIDs []string) {
+	scnMtx.Lock()
+	for _, componentID := range componentIDs {
+		pendingSCNComponents[componentID] = true
+	}
+	scnMtx.Unlock()
+
+	select {
+	case trueUpSCNRunNow <- true:
+	default:
+		// There is already a run waiting which will pick these components up.
+	}
+}
+
+// takeSCNComponents returns every pending component and clears the pending set.
+func takeSCNComponents() (componentIDs []string) {
+	scnMtx.Lock()
+	defer scnMtx.Unlock()
+
+	for componentID := range pendingSCNComponents {
+		componentIDs = append(componentIDs, componentID)
+	}
+	sort.Strings(componentIDs)
+
+	pendingSCNComponents = make(map[string]bool)
+
+	return
+}
+
+// maintainHSMSCNSubscription subscribes to HSM state change notifications and checks the subscription is still there
+// at the true up loop interval. HSM may well not be up yet when the manager starts, and it forgets every subscription
+// if its database is reset, without this the notifications would silently stop.
+func maintainHSMSCNSubscription() {
+	wasSubscribed := false
+	for Running {
+		subscribed, err := isSubscribedToHSMSCN()
+		if err == nil && !subscribed {
+			if wasSubscribed {
+				logger.Warn("HSM no longer has the state change notification subscription, subscribing again.")
+			}
+
+			err = subscribeToHSMSCN()
+			if err == nil {
+				logger.Info("Subscribed to HSM state change notifications.",
+					zap.String("hsmSCNCallbackURL", *hsmSCNCallbackURL))
+			}
+		}
+		if err == nil {
+			wasSubscribed = true
+		} else {
+			logger.Error("Failed to check the HSM state change notification subscription, will retry!",
+				zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Duration(*trueUpSleepInterval) * time.Second):
+		}
+	}
+}
@@ -26,42 +26,66 @@
 package main
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/Cray-HPE/cray-powerdns-manager/internal/common"
 	"github.com/joeig/go-powerdns/v2"
+	"go.uber.org/zap"
 	"io/ioutil"
 	"net/http"
+	"os"
 	"strings"
+	"sync"
 )
 
 const tsigExtension = ".tsig"
 
 var (
-	DNSKeys []common.DNSKey
+	DNSKeys    []common.DNSKey
+	dnsKeysMtx sync.RWMutex
 )
 
-func ParseDNSKeys() error {
+// getDNSKeys returns a copy of the keys so they can be used while the key directory is being reloaded.
+func getDNSKeys() []common.DNSKey {
+	dnsKeysMtx.RLock()
+	defer dnsKeysMtx.RUnlock()
+
+	return append([]common.DNSKey{}, DNSKeys...)
+}
+
+// KeyFileError is why a file in the key directory was rejected.
+type KeyFileError struct {
+	Name string
+	Type common.DNSKeyType
+	Err  error
+}
+
+// ParseDNSKeys reads and validates every key in the key directory. Files that aren't valid keys are returned as errors
+// rather than loaded, if a previous version of the key was valid that version is kept so a bad edit doesn't take a
+// working key away.
+func ParseDNSKeys() (keyErrors []KeyFileError, err error) {
 	if *keyDirectory == "" {
-		return fmt.Errorf("blank key directory")
+		err = fmt.Errorf("blank key directory")
+		return
 	}
 
 	files, err := ioutil.ReadDir(*keyDirectory)
 	if err != nil {
-		return fmt.Errorf("failed to read key directory: %w", err)
+		err = fmt.Errorf("failed to read key directory: %w", err)
+		return
 	}
 
+	previousKeys := make(map[string]common.DNSKey)
+	for _, key := range getDNSKeys() {
+		previousKeys[key.Name] = key
+	}
+
+	var dnsKeys []common.DNSKey
 	for _, privateKeyFile := range files {
 		if privateKeyFile.IsDir() || strings.HasPrefix(privateKeyFile.Name(), ".") {
 			continue
 		}
 
-		privateKeyData, err := ioutil.ReadFile(fmt.Sprintf("%s/%s", *keyDirectory, privateKeyFile.Name()))
-		if err != nil {
-			return fmt.Errorf("failed to read key file: %w", err)
-		}
-
 		var keyType common.DNSKeyType
 		keyName := privateKeyFile.Name()
 
@@ -75,14 +99,79 @@ func ParseDNSKeys() error {
 			keyType = common.DNSSecKeyType
 		}
 
-		DNSKeys = append(DNSKeys, common.DNSKey{
-			Name: keyName,
-			Data: string(privateKeyData),
-			Type: keyType,
-		})
+		dnsKey, keyErr := parseDNSKey(privateKeyFile.Name(), keyName, keyType)
+		if keyErr != nil {
+			keyErrors = append(keyErrors, KeyFileError{
+				Name: keyName,
+				Type: keyType,
+				Err:  fmt.Errorf("%s: %w", privateKeyFile.Name(), keyErr),
+			})
+
+			if previousKey, found := previousKeys[keyName]; found && previousKey.Type == keyType {
+				dnsKeys = append(dnsKeys, previousKey)
+			}
+			continue
+		}
+
+		dnsKeys = append(dnsKeys, dnsKey)
 	}
 
-	return nil
+	dnsKeysMtx.Lock()
+	DNSKeys = dnsKeys
+	dnsKeysMtx.Unlock()
+
+	return
+}
+
+// parseDNSKey reads a single file from the key directory and checks it is a valid key of the given type.
+func parseDNSKey(fileName string, keyName string, keyType common.DNSKeyType) (dnsKey common.DNSKey, err error) {
+	filePath := fmt.Sprintf("%s/%s", *keyDirectory, fileName)
+
+	// Stat rather than use what ReadDir found as Kubernetes mounts secrets as symlinks.
+	fileInfo, err := os.Stat(filePath)
+	if err != nil {
+		err = fmt.Errorf("failed to stat key file: %w", err)
+		return
+	}
+	if !fileInfo.Mode().IsRegular() {
+		err = fmt.Errorf("not a regular file")
+		return
+	}
+	if fileInfo.Size() > maxKeyFileSize {
+		err = fmt.Errorf("file is %d bytes, too big to be a key", fileInfo.Size())
+		return
+	}
+
+	privateKeyData, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		err = fmt.Errorf("failed to read key file: %w", err)
+		return
+	}
+
+	dnsKey = common.DNSKey{
+		Name: keyName,
+		Data: string(privateKeyData),
+		Type: keyType,
+	}
+
+	if keyType == common.TSIGKeyType {
+		// A TSIG key can be limited to some zones or secondaries by listing them alongside the key itself.
+		var tsigKey tsigKeyFile
+		tsigKey, err = validateTSIGKey(keyName, privateKeyData)
+		if err != nil {
+			return
+		}
+		if len(tsigKey.unknownFields) > 0 {
+			logger.Warn("Ignoring unknown fields in TSIG key file", zap.String("fileName", fileName),
+				zap.Strings("unknownFields", tsigKey.unknownFields))
+		}
+		dnsKey.Zones = tsigKey.Zones
+		dnsKey.Secondaries = tsigKey.Secondaries
+	} else {
+		err = validateDNSSECKey(privateKeyData)
+	}
+
+	return
 }
 
 func AddCryptokeyToZone(key common.DNSKey) error {
@@ -100,17 +189,28 @@ func AddCryptokeyToZone(key common.DNSKey) error {
 	return nil
 }
 
+// DeleteTSIGKey removes a TSIG key from PowerDNS, it is not an error if the key is already gone.
+func DeleteTSIGKey(keyName string) error {
+	err := pdns.TSIGKeys.Delete(keyName)
+	var pdnsErr *powerdns.Error
+	if err != nil && !(errors.As(err, &pdnsErr) && pdnsErr.StatusCode == http.StatusNotFound) {
+		return fmt.Errorf("failed to delete TSIG key: %w", err)
+	}
+
+	return nil
+}
+
 func AddOrUpdateTSIGKey(key common.DNSKey) error {
 	var existingTSIGKey *powerdns.TSIGKey
-	var newTSIGKey *powerdns.TSIGKey
 	var err error
 
 	// The data in the DNSKey is actually a JSON block that if the user did as instructed can be natively unmarshalled
-	// into the PowerDNS struct.
-	jsonErr := json.Unmarshal([]byte(key.Data), &newTSIGKey)
-	if jsonErr != nil {
-		return fmt.Errorf("failed to unmarshal TSIG key: %w", jsonErr)
+	// into the PowerDNS struct. Validating it again makes sure there is a key to compare against.
+	tsigKey, validateErr := validateTSIGKey(key.Name, []byte(key.Data))
+	if validateErr != nil {
+		return fmt.Errorf("invalid TSIG key: %w", validateErr)
 	}
+	newTSIGKey := &tsigKey.TSIGKey
 
 	// Get any existing key by this name.
 	existingTSIGKey, err = pdns.TSIGKeys.Get(key.Name)
@@ -129,8 +229,9 @@ func AddOrUpdateTSIGKey(key common.DNSKey) error {
 
 	// At this point the key either has a value in the structure or it's nil. Check if we need to add or update.
 	var addOrUpdateErr error
-	if existingTSIGKey.Key != nil {
-		if *existingTSIGKey.Key != *newTSIGKey.Key {
+	if existingTSIGKey != nil && existingTSIGKey.Key != nil {
+		if *existingTSIGKey.Key != *newTSIGKey.Key || existingTSIGKey.Algorithm == nil ||
+			strings.TrimSuffix(*existingTSIGKey.Algorithm, ".") != strings.TrimSuffix(*newTSIGKey.Algorithm, ".") {
 			_, addOrUpdateErr = pdns.TSIGKeys.Replace(key.Name, newTSIGKey)
 		}
 	} else {
@@ -0,0 +1,152 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/Cray-HPE/cray-powerdns-manager/internal/common"
+	"github.com/joeig/go-powerdns/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// StaticRecords is the content of the static records file, extra zones and RRsets that are part of the desired state
+// alongside what is generated from SLS and HSM. The file is YAML, which also means JSON works.
+type StaticRecords struct {
+	// Zones are extra master zones to create, the records can be in these as well as the base domain and reverse zones.
+	Zones   []string       `json:"zones" yaml:"zones"`
+	Records []ManualRecord `json:"records" yaml:"records"`
+}
+
+// loadStaticRecords reads and validates the static records file. No file means no static records.
+func loadStaticRecords(path string) (staticRecords StaticRecords, err error) {
+	if path == "" {
+		return
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		err = fmt.Errorf("failed to read static records file %s: %w", path, err)
+		return
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	err = decoder.Decode(&staticRecords)
+	if err != nil && !errors.Is(err, io.EOF) {
+		err = fmt.Errorf("failed to parse static records file %s: %w", path, err)
+		return
+	}
+
+	err = staticRecords.normalize()
+	if err != nil {
+		err = fmt.Errorf("invalid static records file %s: %w", path, err)
+	}
+
+	return
+}
+
+// normalize puts the zones and records into the same form the generated ones are in and checks they make sense
+// together.
+func (staticRecords *StaticRecords) normalize() error {
+	var zoneNames []string
+	for _, zoneName := range staticRecords.Zones {
+		zoneName = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(zoneName)), ".")
+		if zoneName == "" {
+			return fmt.Errorf("zone names can't be empty")
+		}
+		if strings.Contains(zoneName, "/") {
+			return fmt.Errorf("zone name %s can't contain a slash", zoneName)
+		}
+		if !common.SliceContains(zoneName, zoneNames) {
+			zoneNames = append(zoneNames, zoneName)
+		}
+	}
+	staticRecords.Zones = zoneNames
+
+	// The reverse zones come from SLS so all that can be checked here is that the record is in the reverse tree, true
+	// up runs check it is in one of the reverse zones with checkZones.
+	zoneNames = append([]string{*baseDomain, "in-addr.arpa", "ip6.arpa"}, staticRecords.Zones...)
+
+	recordTypes := make(map[string]map[powerdns.RRType]bool)
+	for i := range staticRecords.Records {
+		record := &staticRecords.Records[i]
+
+		err := normalizeManualRecord(record, zoneNames)
+		if err != nil {
+			return err
+		}
+
+		types := recordTypes[record.Name]
+		if types == nil {
+			types = make(map[powerdns.RRType]bool)
+			recordTypes[record.Name] = types
+		}
+		if types[record.Type] {
+			return fmt.Errorf("%w: %s %s is defined more than once", errManualRecordInvalid, record.Name,
+				record.Type)
+		}
+		if (record.Type == powerdns.RRTypeCNAME && len(types) > 0) || types[powerdns.RRTypeCNAME] {
+			return fmt.Errorf("%w: %s can't have a CNAME and other records", errManualRecordInvalid, record.Name)
+		}
+		types[record.Type] = true
+	}
+
+	sort.Slice(staticRecords.Records, func(i, j int) bool {
+		if staticRecords.Records[i].Name == staticRecords.Records[j].Name {
+			return staticRecords.Records[i].Type < staticRecords.Records[j].Type
+		}
+		return staticRecords.Records[i].Name < staticRecords.Records[j].Name
+	})
+
+	return nil
+}
+
+// checkZones makes sure every record is in one of the zones, the reverse zones in particular aren't known until the
+// zones have been trued up.
+func (staticRecords StaticRecords) checkZones(zones common.PowerDNSZones) error {
+	err := checkManualRecordZones(staticRecords.Records, zones)
+	if err != nil {
+		return fmt.Errorf("invalid static records: %w", err)
+	}
+
+	return nil
+}
+
+// getRRSets returns the RRsets for the static records.
+func (staticRecords StaticRecords) getRRSets() (rrSets []powerdns.RRset) {
+	for _, record := range staticRecords.Records {
+		rrSets = append(rrSets, record.toRRset(common.GetStaticRecordOwnership(record.Name, string(record.Type))))
+	}
+
+	return
+}
@@ -0,0 +1,178 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/Cray-HPE/cray-powerdns-manager/internal/common"
+	"github.com/joeig/go-powerdns/v2"
+)
+
+func TestLoadStaticRecords(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		wantZones []string
+		want      map[string][]string
+		wantErr   bool
+	}{
+		{
+			name:    "empty file",
+			content: "",
+			want:    map[string][]string{},
+		},
+		{
+			name: "zones and records are normalized",
+			content: `
+zones:
+  - Extra.Example.
+records:
+  - name: WWW.extra.example
+    type: a
+    records: ["10.1.1.2", "10.1.1.1"]
+  - name: _ldap._tcp.extra.example
+    type: SRV
+    ttl: 300
+    records: ["0 5 389 ldap.extra.example."]
+`,
+			wantZones: []string{"extra.example"},
+			want: map[string][]string{
+				"_ldap._tcp.extra.example. SRV": {"0 5 389 ldap.extra.example."},
+				"www.extra.example. A":          {"10.1.1.2", "10.1.1.1"},
+			},
+		},
+		{
+			name:    "JSON works too",
+			content: `{"records": [{"name": "api.example.com", "type": "CNAME", "records": ["api-gw.example.com."]}]}`,
+			want:    map[string][]string{"api.example.com. CNAME": {"api-gw.example.com."}},
+		},
+		{
+			name:    "unknown fields",
+			content: "zone: [extra.example]\n",
+			wantErr: true,
+		},
+		{
+			name:    "zone name with a slash",
+			content: "zones: [0/26.1.168.192.in-addr.arpa]\n",
+			wantErr: true,
+		},
+		{
+			name: "duplicate records",
+			content: `
+records:
+  - {name: www.example.com, type: A, records: ["10.1.1.1"]}
+  - {name: www.example.com, type: A, records: ["10.1.1.2"]}
+`,
+			wantErr: true,
+		},
+		{
+			name: "CNAME and other records",
+			content: `
+records:
+  - {name: www.example.com, type: A, records: ["10.1.1.1"]}
+  - {name: www.example.com, type: CNAME, records: ["web.example.com."]}
+`,
+			wantErr: true,
+		},
+		{
+			name:    "TXT that isn't quoted",
+			content: "records: [{name: example.com, type: TXT, records: [\"v=spf1 -all\"]}]\n",
+			wantErr: true,
+		},
+		{
+			name:    "SRV without a port",
+			content: "records: [{name: _ldap._tcp.example.com, type: SRV, records: [\"0 5 ldap.example.com.\"]}]\n",
+			wantErr: true,
+		},
+		{
+			name:    "MX without a preference",
+			content: "records: [{name: example.com, type: MX, records: [mail.example.com.]}]\n",
+			wantErr: true,
+		},
+		{
+			name:    "CAA with an unquoted value",
+			content: "records: [{name: example.com, type: CAA, records: [\"0 issue letsencrypt.org\"]}]\n",
+			wantErr: true,
+		},
+		{
+			name:    "record outside of every zone",
+			content: "records: [{name: www.elsewhere.example, type: A, records: [\"10.1.1.1\"]}]\n",
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "static.yaml")
+			if err := os.WriteFile(path, []byte(test.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			staticRecords, err := loadStaticRecords(path)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("loadStaticRecords() error = %v, wantErr %v", err, test.wantErr)
+			}
+			if test.wantErr {
+				return
+			}
+
+			if !reflect.DeepEqual(staticRecords.Zones, test.wantZones) {
+				t.Errorf("loadStaticRecords() zones = %v, want %v", staticRecords.Zones, test.wantZones)
+			}
+			got := getTestRRsetContents(staticRecords.getRRSets())
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("loadStaticRecords() records = %v, want %v", got, test.want)
+			}
+		})
+	}
+
+	t.Run("no file", func(t *testing.T) {
+		staticRecords, err := loadStaticRecords("")
+		if err != nil || len(staticRecords.Zones) != 0 || len(staticRecords.Records) != 0 {
+			t.Errorf("loadStaticRecords() = %v, %v, want no records", staticRecords, err)
+		}
+	})
+}
+
+func TestStaticRecordsCheckZones(t *testing.T) {
+	staticRecords := StaticRecords{
+		Records: []ManualRecord{{Name: "100.1.252.10.in-addr.arpa.", Type: powerdns.RRTypePTR}},
+	}
+
+	zones := common.PowerDNSZones{{Name: powerdns.String("example.com.")}}
+	if err := staticRecords.checkZones(zones); err == nil {
+		t.Errorf("checkZones() error = nil, want the record outside the reverse zones rejected")
+	}
+
+	zones = append(zones, &powerdns.Zone{Name: powerdns.String("252.10.in-addr.arpa.")})
+	if err := staticRecords.checkZones(zones); err != nil {
+		t.Errorf("checkZones() error = %v, want nil", err)
+	}
+}
@@ -33,6 +33,7 @@ import (
 	"time"
 
 	"github.com/Cray-HPE/cray-powerdns-manager/internal/common"
+	"github.com/Cray-HPE/cray-powerdns-manager/internal/sls"
 	base "github.com/Cray-HPE/hms-base"
 	sls_common "github.com/Cray-HPE/hms-sls/pkg/sls-common"
 	"github.com/Cray-HPE/hms-smd/pkg/sm"
@@ -41,9 +42,15 @@ import (
 	"go.uber.org/zap"
 )
 
-func ensureMasterZone(zoneName string, nameserverFQDNs []string, rrSets []powerdns.RRset) (masterZone *powerdns.Zone) {
+// ensureMasterZone gets the master zone, creating it if it doesn't exist. For a dry run the zone is only recorded in
+// the plan as needing to be created and a placeholder containing the RRsets it would be created with is returned.
+func ensureMasterZone(zoneName string, nameserverFQDNs []string, rrSets []powerdns.RRset, plan *Plan,
+	dryRun bool) (masterZone *powerdns.Zone) {
+	plan.addDesiredZone(zoneName)
+
 	var err error
 	masterZone, err = pdns.Zones.Get(zoneName)
+	recordPowerDNSResult(err)
 	if err != nil {
 		masterZone = nil
 
@@ -53,24 +60,25 @@ func ensureMasterZone(zoneName string, nameserverFQDNs []string, rrSets []powerd
 			return
 		} else {
 			if pdnsErr.StatusCode == http.StatusNotFound {
-				// Figure out if this zone has a custom DNSSEC key.
-				var customDNSSECKey *common.DNSKey
-				var tsigKeyIDs []string
-				for _, key := range DNSKeys {
-					if strings.TrimSuffix(zoneName, ".") == key.Name {
-						// Required because the loop variable itself is a reference.
-						tmpKey := key
-						customDNSSECKey = &tmpKey
-					}
-					if key.Type == common.TSIGKeyType {
-						tsigKeyIDs = append(tsigKeyIDs, key.Name)
+				plan.getZonePlan(zoneName).CreateZone = true
+				if dryRun {
+					logger.Info("Master zone would be added", zap.String("zoneName", zoneName))
+					masterZone = &powerdns.Zone{
+						Name:   powerdns.String(common.MakeDomainCanonical(zoneName)),
+						RRsets: rrSets,
 					}
+					return
 				}
 
+				// Figure out if this zone has a custom DNSSEC key.
+				customDNSSECKey, tsigKeyIDs := getZoneKeys(zoneName)
+
 				zone := &powerdns.Zone{
 					Name:             &zoneName,
 					Kind:             powerdns.ZoneKindPtr(powerdns.MasterZoneKind),
 					DNSsec:           powerdns.Bool(false),
+					Account:          powerdns.String(common.ManagerAccount),
+					SOAEditAPI:       getSOAEditAPI(),
 					Nameservers:      nameserverFQDNs,
 					RRsets:           rrSets,
 					MasterTSIGKeyIDs: tsigKeyIDs,
@@ -80,6 +88,7 @@ func ensureMasterZone(zoneName string, nameserverFQDNs []string, rrSets []powerd
 				if err != nil {
 					logger.Error("Failed to add master zone!",
 						zap.Error(err), zap.Any("masterZone", *zone))
+					plan.getZonePlan(zoneName).Error = fmt.Sprintf("failed to add zone: %s", err)
 					return
 				} else {
 					logger.Info("Added master zone", zap.String("zoneName", zoneName))
@@ -89,6 +98,12 @@ func ensureMasterZone(zoneName string, nameserverFQDNs []string, rrSets []powerd
 						logger.Error("Failed to rectify zone", zap.String("zoneName", zoneName), zap.Error(err))
 					}
 
+					// Record the TSIG keys as the manager's so they can be taken away again later.
+					if ownershipErr := setZoneOwnedTSIGKeys(zoneName, tsigKeyIDs); ownershipErr != nil {
+						logger.Error("Failed to record zone TSIG key ownership!", zap.String("zoneName", zoneName),
+							zap.Error(ownershipErr))
+					}
+
 					// Now that the zone is added we check to see if we found a custom DNSSEC key and if so upload that.
 					if customDNSSECKey != nil {
 						err = AddCryptokeyToZone(*customDNSSECKey)
@@ -106,18 +121,32 @@ func ensureMasterZone(zoneName string, nameserverFQDNs []string, rrSets []powerd
 			}
 		}
 
-		// TODO: Add logic to update the master zone if necessary.
 	}
 
 	logger.Debug("Master zone already exists.", zap.String("masterZone.name", *masterZone.Name))
 
+	// Make sure the zone still matches the flags, they may have changed since it was created.
+	if soa, found := getSOA(rrSets); found {
+		reconcileZone(masterZone, nameserverFQDNs, soa, plan, dryRun)
+	}
+
 	return
 }
 
-func trueUpMasterZones(baseDomain string, networks []sls_common.Network,
-	masterNameserver common.Nameserver, slaveNameservers []common.Nameserver) (masterZones []*powerdns.Zone) {
+// zoneSpec is a zone the manager wants along with the nameservers and RRsets it should be created with.
+type zoneSpec struct {
+	name            string
+	nameserverFQDNs []string
+	rrSets          []powerdns.RRset
+}
+
+// getMasterZoneSpecs returns the base domain zone, the zone for every network, plus the short DNAME zones if they are
+// enabled, and any extra zones from the static records file.
+func getMasterZoneSpecs(baseDomain string, networks []sls_common.Network, staticZoneNames []string,
+	masterNameserver common.Nameserver, slaveNameservers []common.Nameserver) (zoneSpecs []zoneSpec) {
 	// Create a list of all the master zones.
 	masterZoneNames := []string{baseDomain}
+	shortZoneNames := make(map[string]bool)
 	for _, network := range networks {
 		networkDomain := strings.ToLower(network.Name)
 		fullDomain := fmt.Sprintf("%s.%s", networkDomain, baseDomain)
@@ -125,11 +154,17 @@ func trueUpMasterZones(baseDomain string, networks []sls_common.Network,
 		masterZoneNames = append(masterZoneNames, fullDomain)
 		if *createDNAME == true {
 			masterZoneNames = append(masterZoneNames, networkDomain)
+			shortZoneNames[networkDomain] = true
+		}
+	}
+	for _, staticZoneName := range staticZoneNames {
+		if !common.SliceContains(staticZoneName, masterZoneNames) {
+			masterZoneNames = append(masterZoneNames, staticZoneName)
 		}
 	}
 
 	// Every zone should have at least the master nameserver.
-	masterNameserverRRSet := common.GetNameserverRRset(masterNameserver)
+	masterNameserverRRSet := common.GetNameserverRRset(masterNameserver, recordTTLs)
 	baseNameserverFQDNs := []string{*masterNameserverRRSet.Name}
 
 	for _, masterZoneName := range masterZoneNames {
@@ -153,7 +188,7 @@ func trueUpMasterZones(baseDomain string, networks []sls_common.Network,
 					ns := powerdns.RRset{
 						Name:       powerdns.String(common.MakeDomainCanonical(zone)),
 						Type:       powerdns.RRTypePtr(powerdns.RRTypeNS),
-						TTL:        powerdns.Uint32(3600),
+						TTL:        powerdns.Uint32(recordTTLs.Get(powerdns.RRTypeNS, "")),
 						ChangeType: powerdns.ChangeTypePtr(powerdns.ChangeTypeReplace),
 						Records: []powerdns.Record{
 							{
@@ -168,9 +203,10 @@ func trueUpMasterZones(baseDomain string, networks []sls_common.Network,
 		}
 
 		// This is a short zone that requires a DNAME pointer to the fully qualified zone
-		if !strings.HasSuffix(masterZoneName, baseDomain) && *createDNAME == true {
+		if shortZoneNames[masterZoneName] {
 			logger.Debug("Found short zone name, creating DNAME record", zap.String("masterZoneName", masterZoneName))
-			dnameRRSet, err := common.GetDNAMERRSet(masterZoneName, baseDomain, masterZoneNames)
+			dnameRRSet, err := common.GetDNAMERRSet(masterZoneName, baseDomain, masterZoneNames,
+				recordTTLs.Get(powerdns.RRTypeDNAME, ""))
 			if err == nil {
 				logger.Debug("Adding DNAME RRSet to zone", zap.Any("RRSet", dnameRRSet))
 				nameserverRRSets = append(nameserverRRSets, dnameRRSet)
@@ -194,137 +230,213 @@ func trueUpMasterZones(baseDomain string, networks []sls_common.Network,
 
 		// Now figure out if this zone is enabled for zone transfers and if so add the slave server(s) to the
 		// name server list.
-		if len(notifyZonesArray) == 0 || common.SliceContains(masterZoneName, notifyZonesArray) {
+		if isNotifyZone(masterZoneName) {
 			for _, nameserver := range slaveNameservers {
 				nameserverFQDNs = append(nameserverFQDNs, nameserver.FQDN)
 			}
 		}
 
-		masterZone := ensureMasterZone(masterZoneName, nameserverFQDNs, nameserverRRSets)
-		if masterZone != nil && masterZone.ID != nil {
+		zoneSpecs = append(zoneSpecs, zoneSpec{
+			name:            masterZoneName,
+			nameserverFQDNs: nameserverFQDNs,
+			rrSets:          nameserverRRSets,
+		})
+	}
+
+	return
+}
+
+func trueUpMasterZones(baseDomain string, networks []sls_common.Network, staticZoneNames []string,
+	masterNameserver common.Nameserver, slaveNameservers []common.Nameserver, plan *Plan,
+	dryRun bool) (masterZones []*powerdns.Zone) {
+	for _, spec := range getMasterZoneSpecs(baseDomain, networks, staticZoneNames, masterNameserver,
+		slaveNameservers) {
+		masterZone := ensureMasterZone(spec.name, spec.nameserverFQDNs, spec.rrSets, plan, dryRun)
+		if masterZone != nil && masterZone.Name != nil {
 			masterZones = append(masterZones, masterZone)
 		}
 	}
 
-	// TODO: Remove any zones that don't correspond to networks from SLS.
+	return
+}
+
+// getReverseZoneNameserverFQDNs returns the nameservers for a reverse zone. The master is always one of them and the
+// slaves are too if this zone is enabled for zone transfers.
+func getReverseZoneNameserverFQDNs(reverseZoneName string, masterNameserver common.Nameserver,
+	slaveNameservers []common.Nameserver) (nameserverFQDNs []string) {
+	masterNameserverRRSet := common.GetNameserverRRset(masterNameserver, recordTTLs)
+	nameserverFQDNs = append(nameserverFQDNs, *masterNameserverRRSet.Name)
+
+	if isNotifyZone(reverseZoneName) {
+		for _, nameserver := range slaveNameservers {
+			nameserverRRSet := common.GetNameserverRRset(nameserver, recordTTLs)
+
+			nameserverFQDNs = append(nameserverFQDNs, *nameserverRRSet.Name)
+		}
+	}
 
 	return
 }
 
-func trueUpReverseZones(networks []sls_common.Network,
-	masterNameserver common.Nameserver, slaveNameservers []common.Nameserver) (reverseZones []*powerdns.Zone,
-	err error) {
-networks:
+// getReverseZoneSpecs returns a reverse zone for every IPv4 range of every network and every IPv6 network. Any range
+// that isn't a valid CIDR is an error as the reverse zones wouldn't be complete without it.
+func getReverseZoneSpecs(networks []sls_common.Network, ipv6Networks []common.NetworkNameCIDRMap,
+	masterNameserver common.Nameserver, slaveNameservers []common.Nameserver) (zoneSpecs []zoneSpec, err error) {
+	// IPv6 networks only come from cabinet prefixes or are already in the network IP ranges, either way they get a
+	// reverse zone too.
+	var networkNameCIDRMaps []common.NetworkNameCIDRMap
 	for _, network := range networks {
 		for _, ipRange := range network.IPRanges {
-			var nameserverFQDNs []string
-			var nameserverRRSets []powerdns.RRset
-
-			// Compute the correct name.
 			var cidr *net.IPNet
 			_, cidr, err = net.ParseCIDR(ipRange)
 			if err != nil {
 				return
 			}
 
-			reverseZoneName := common.GetReverseZoneName(cidr)
-			logger.Debug("Calculated reverse zone name:", zap.Any("sls_network", network.Name),
-				zap.Any("cidr", cidr), zap.Any("reverseZoneName", reverseZoneName))
-
-			/*
-				As reverse zones split on a /24 boundary it's possible for two SLS subnets to map to the same reverse
-				zone. For example a CAN of 10.101.5.128/26 and a CMN of 10.101.5.0/25 would map to the same
-				5.101.10.in-addr.arpa zone. This avoids adding the same zone to the reverseZones array twice
-			*/
-			for _, zone := range reverseZones {
-				if strings.Contains(*zone.Name, reverseZoneName) {
-					logger.Debug("Master zone already exists.", zap.String("reverseZoneName", reverseZoneName))
-					continue networks
-				}
+			networkNameCIDRMaps = append(networkNameCIDRMaps, common.NetworkNameCIDRMap{
+				Name: network.Name,
+				CIDR: cidr,
+			})
+
+			// A classless zone is useless without the /24 parent that delegates to it.
+			if isClassless(cidr) {
+				networkNameCIDRMaps = append(networkNameCIDRMaps, common.NetworkNameCIDRMap{
+					Name: network.Name,
+					CIDR: getClasslessParentCIDR(cidr),
+				})
 			}
+		}
+	}
+	networkNameCIDRMaps = append(networkNameCIDRMaps, ipv6Networks...)
 
-			// This master name server is always listed as one of the nameservers.
-			masterNameserverRRSet := common.GetNameserverRRset(masterNameserver)
-			nameserverFQDNs = append(nameserverFQDNs, *masterNameserverRRSet.Name)
-
-			// Build valid SOA record
-			soa := common.GetStartOfAuthorityRRSet(reverseZoneName,
-				*masterNameserverRRSet.Name,
-				fmt.Sprintf("hostmaster.%s", common.MakeDomainCanonical(*baseDomain)),
-				*soaRefresh,
-				*soaRetry,
-				*soaExpiry,
-				*soaMinimum,
-			)
-			nameserverRRSets = append(nameserverRRSets, soa)
-
-			// Now figure out if this zone is enabled for zone transfers and if so add the slave server(s) to the
-			// name server list.
-			if len(notifyZonesArray) == 0 || common.SliceContains(reverseZoneName, notifyZonesArray) {
-				for _, nameserver := range slaveNameservers {
-					nameserverRRSet := common.GetNameserverRRset(nameserver)
-
-					nameserverFQDNs = append(nameserverFQDNs, *nameserverRRSet.Name)
-				}
+networks:
+	for _, network := range networkNameCIDRMaps {
+		// Compute the correct name.
+		cidr := network.CIDR
+
+		reverseZoneName := getReverseZoneName(cidr)
+		logger.Debug("Calculated reverse zone name:", zap.Any("sls_network", network.Name),
+			zap.Any("cidr", cidr), zap.Any("reverseZoneName", reverseZoneName))
+
+		/*
+			As reverse zones split on a /24 boundary it's possible for two SLS subnets to map to the same reverse
+			zone. For example a CAN of 10.101.5.128/26 and a CMN of 10.101.5.0/25 would map to the same
+			5.101.10.in-addr.arpa zone. This avoids adding the same zone twice. The names have to match exactly as an
+			RFC 2317 classless zone contains the name of its parent.
+		*/
+		for _, spec := range zoneSpecs {
+			if spec.name == reverseZoneName {
+				logger.Debug("Master zone already exists.", zap.String("reverseZoneName", reverseZoneName))
+				continue networks
 			}
+		}
 
-			var reverseZone *powerdns.Zone
-			reverseZone, err = pdns.Zones.Get(reverseZoneName)
-			if err == nil {
-				// TODO: Add logic to make sure all the details are correct and remove reverse zones if necessary.
-				reverseZones = append(reverseZones, reverseZone)
+		masterNameserverRRSet := common.GetNameserverRRset(masterNameserver, recordTTLs)
+
+		// Build valid SOA record
+		soa := common.GetStartOfAuthorityRRSet(reverseZoneName,
+			*masterNameserverRRSet.Name,
+			fmt.Sprintf("hostmaster.%s", common.MakeDomainCanonical(*baseDomain)),
+			*soaRefresh,
+			*soaRetry,
+			*soaExpiry,
+			*soaMinimum,
+		)
+
+		zoneSpecs = append(zoneSpecs, zoneSpec{
+			name:            reverseZoneName,
+			nameserverFQDNs: getReverseZoneNameserverFQDNs(reverseZoneName, masterNameserver, slaveNameservers),
+			rrSets:          []powerdns.RRset{soa},
+		})
+	}
+
+	return
+}
+
+func trueUpReverseZones(networks []sls_common.Network, ipv6Networks []common.NetworkNameCIDRMap,
+	masterNameserver common.Nameserver, slaveNameservers []common.Nameserver, plan *Plan,
+	dryRun bool) (reverseZones []*powerdns.Zone, err error) {
+	zoneSpecs, err := getReverseZoneSpecs(networks, ipv6Networks, masterNameserver, slaveNameservers)
+	if err != nil {
+		return
+	}
+
+	for _, spec := range zoneSpecs {
+		reverseZoneName := spec.name
+		nameserverFQDNs := spec.nameserverFQDNs
+		nameserverRRSets := spec.rrSets
+		plan.addDesiredZone(reverseZoneName)
+
+		var reverseZone *powerdns.Zone
+		reverseZone, err = pdns.Zones.Get(reverseZoneName)
+		recordPowerDNSResult(err)
+		if err == nil {
+			if soa, found := getSOA(nameserverRRSets); found {
+				reconcileZone(reverseZone, nameserverFQDNs, soa, plan, dryRun)
+			}
+			reverseZones = append(reverseZones, reverseZone)
+		} else {
+			pdnsErr, ok := err.(*powerdns.Error)
+			if !ok {
+				logger.Error("Error not a PowerDNS error type!", zap.Error(err))
+				return
 			} else {
-				pdnsErr, ok := err.(*powerdns.Error)
-				if !ok {
-					logger.Error("Error not a PowerDNS error type!", zap.Error(err))
-					return
-				} else {
-					if pdnsErr.StatusCode == http.StatusNotFound {
-						// Figure out if this zone has any custom keys.
-						var customDNSSECKey *common.DNSKey
-						var tsigKeyIDs []string
-						for _, key := range DNSKeys {
-							if strings.TrimSuffix(reverseZoneName, ".") == key.Name {
-								// Required because the loop variable itself is a reference.
-								tmpKey := key
-								customDNSSECKey = &tmpKey
-							}
-							if key.Type == common.TSIGKeyType {
-								tsigKeyIDs = append(tsigKeyIDs, key.Name)
-							}
-						}
+				if pdnsErr.StatusCode == http.StatusNotFound {
+					plan.getZonePlan(reverseZoneName).CreateZone = true
+					if dryRun {
+						logger.Info("Reverse zone would be added", zap.String("reverseZoneName", reverseZoneName))
+						reverseZones = append(reverseZones, &powerdns.Zone{
+							Name:   powerdns.String(common.MakeDomainCanonical(reverseZoneName)),
+							RRsets: nameserverRRSets,
+						})
+						err = nil
+						continue
+					}
 
-						reverseZone = &powerdns.Zone{
-							Name:             &reverseZoneName,
-							Kind:             powerdns.ZoneKindPtr(powerdns.MasterZoneKind),
-							DNSsec:           powerdns.Bool(false),
-							Nameservers:      nameserverFQDNs,
-							MasterTSIGKeyIDs: tsigKeyIDs,
-							RRsets:           nameserverRRSets,
+					// Figure out if this zone has any custom keys.
+					customDNSSECKey, tsigKeyIDs := getZoneKeys(reverseZoneName)
+
+					reverseZone = &powerdns.Zone{
+						Name:             &reverseZoneName,
+						Kind:             powerdns.ZoneKindPtr(powerdns.MasterZoneKind),
+						DNSsec:           powerdns.Bool(false),
+						Account:          powerdns.String(common.ManagerAccount),
+						SOAEditAPI:       getSOAEditAPI(),
+						Nameservers:      nameserverFQDNs,
+						MasterTSIGKeyIDs: tsigKeyIDs,
+						RRsets:           nameserverRRSets,
+					}
+					var addedReverseZone *powerdns.Zone
+					addedReverseZone, err = pdns.Zones.Add(reverseZone)
+					if err != nil {
+						logger.Error("Failed to add reverse zone!",
+							zap.Error(err), zap.Any("reverseZone", *reverseZone))
+						plan.getZonePlan(reverseZoneName).Error = fmt.Sprintf("failed to add zone: %s", err)
+						return
+					} else {
+						reverseZone = addedReverseZone
+						logger.Info("Added reverse zone", zap.Any("reverseZone", reverseZone))
+						reverseZones = append(reverseZones, reverseZone)
+
+						// Record the TSIG keys as the manager's so they can be taken away again later.
+						if ownershipErr := setZoneOwnedTSIGKeys(reverseZoneName, tsigKeyIDs); ownershipErr != nil {
+							logger.Error("Failed to record reverse zone TSIG key ownership!",
+								zap.String("reverseZoneName", reverseZoneName), zap.Error(ownershipErr))
 						}
-						reverseZone, err = pdns.Zones.Add(reverseZone)
-						if err != nil {
-							logger.Error("Failed to add reverse zone!",
-								zap.Error(err), zap.Any("reverseZone", *reverseZone))
-							return
-						} else {
-							logger.Info("Added reverse zone", zap.Any("reverseZone", reverseZone))
-							reverseZones = append(reverseZones, reverseZone)
-
-							// Now that the zone is added we check to see if we found a custom DNSSEC key and if so
-							// upload that.
-							if customDNSSECKey != nil {
-								err = AddCryptokeyToZone(*customDNSSECKey)
-
-								if err != nil {
-									logger.Error("Failed to add custom DNSSEC key to reverse zone!",
-										zap.Error(err))
-								}
+
+						// Now that the zone is added we check to see if we found a custom DNSSEC key and if so
+						// upload that.
+						if customDNSSECKey != nil {
+							err = AddCryptokeyToZone(*customDNSSECKey)
+
+							if err != nil {
+								logger.Error("Failed to add custom DNSSEC key to reverse zone!",
+									zap.Error(err))
 							}
 						}
-					} else {
-						logger.Error("Got unknown PowerDNS error!", zap.Any("pdnsErr", pdnsErr))
 					}
+				} else {
+					logger.Error("Got unknown PowerDNS error!", zap.Any("pdnsErr", pdnsErr))
 				}
 			}
 		}
@@ -336,10 +448,7 @@ networks:
 func buildStaticForwardRRSets(networks []sls_common.Network, hardware []sls_common.GenericHardware, state base.ComponentArray) (
 	staticRRSets []powerdns.RRset, err error) {
 	// Build up a map of the hardware to save lookup time later.
-	hardwareMap := make(map[string]sls_common.GenericHardware)
-	for _, device := range hardware {
-		hardwareMap[device.Xname] = device
-	}
+	hardwareMap := sls.GetHardwareMap(hardware)
 
 	// Build up a map of the state data to avoid having to iterate through it for the HSN and CHN records.
 	stateMap := make(map[string]base.Component)
@@ -350,7 +459,7 @@ func buildStaticForwardRRSets(networks []sls_common.Network, hardware []sls_comm
 	for _, network := range networks {
 		networkDomain := strings.ToLower(network.Name)
 
-		var networkProperties NetworkExtraProperties
+		var networkProperties sls.NetworkExtraProperties
 		err = mapstructure.Decode(network.ExtraPropertiesRaw, &networkProperties)
 		if err != nil {
 			return
@@ -358,11 +467,13 @@ func buildStaticForwardRRSets(networks []sls_common.Network, hardware []sls_comm
 
 		for _, subnet := range networkProperties.Subnets {
 			for _, reservation := range subnet.IPReservations {
+				ownership := common.GetSLSReservationOwnership(network.Name, subnet.Name, reservation.Name)
+
 				// Can't believe this is a thing, but, for some reason the xname for some entries is in the comment
 				// field. If that's the case, then we create the A record from that and then a CNAME for the name
 				// and then CNAMEs for each of the aliases.
 				// Start by seeing if this comment corresponds to a hardware object (i.e., is an xname).
-				node, found := hardwareMap[reservation.Comment]
+				node, found := sls.GetReservationNode(reservation, hardwareMap)
 
 				// Now we can build the primary name for the A record.
 				var primaryName string
@@ -375,8 +486,9 @@ func buildStaticForwardRRSets(networks []sls_common.Network, hardware []sls_comm
 						Name: powerdns.String(fmt.Sprintf("%s.%s.%s.",
 							reservation.Name, networkDomain, *baseDomain)),
 						Type:       powerdns.RRTypePtr(powerdns.RRTypeCNAME),
-						TTL:        powerdns.Uint32(3600),
+						TTL:        powerdns.Uint32(recordTTLs.Get(powerdns.RRTypeCNAME, networkDomain)),
 						ChangeType: powerdns.ChangeTypePtr(powerdns.ChangeTypeReplace),
+						Comments:   common.GetOwnershipComments(ownership),
 						Records: []powerdns.Record{
 							{
 								Content:  powerdns.String(primaryName),
@@ -393,8 +505,9 @@ func buildStaticForwardRRSets(networks []sls_common.Network, hardware []sls_comm
 				primaryRRset := powerdns.RRset{
 					Name:       powerdns.String(primaryName),
 					Type:       powerdns.RRTypePtr(powerdns.RRTypeA),
-					TTL:        powerdns.Uint32(3600),
+					TTL:        powerdns.Uint32(recordTTLs.Get(powerdns.RRTypeA, networkDomain)),
 					ChangeType: powerdns.ChangeTypePtr(powerdns.ChangeTypeReplace),
+					Comments:   common.GetOwnershipComments(ownership),
 					Records: []powerdns.Record{
 						{
 							Content:  powerdns.String(string(reservation.IPAddress)),
@@ -405,21 +518,19 @@ func buildStaticForwardRRSets(networks []sls_common.Network, hardware []sls_comm
 				staticRRSets = append(staticRRSets, primaryRRset)
 
 				// Now create CNAME records for each of the aliases.
-				for _, alias := range reservation.Aliases {
-					/* Avoid bad names such as .local etc.
-					   The SLS aliases can contain the node xname which results in this
-					   rather unfortunate DNS record if not removed
-
-					   x3000c0s3b0n0.nmn.drax.dev.cray.com	3600	IN	CNAME	x3000c0s3b0n0.nmn.drax.dev.cray.com.  */
-					if strings.Contains(alias, ".") || alias == node.Xname {
-						continue
-					}
+				/* Avoid bad names such as .local etc.
+				   The SLS aliases can contain the node xname which results in this
+				   rather unfortunate DNS record if not removed
 
+				   x3000c0s3b0n0.nmn.drax.dev.cray.com	3600	IN	CNAME	x3000c0s3b0n0.nmn.drax.dev.cray.com.  */
+				aliases, _ := sls.GetReservationAliases(reservation, node.Xname)
+				for _, alias := range aliases {
 					aliasRRset := powerdns.RRset{
 						Name:       powerdns.String(fmt.Sprintf("%s.%s.%s.", alias, networkDomain, *baseDomain)),
 						Type:       powerdns.RRTypePtr(powerdns.RRTypeCNAME),
-						TTL:        powerdns.Uint32(3600),
+						TTL:        powerdns.Uint32(recordTTLs.Get(powerdns.RRTypeCNAME, networkDomain)),
 						ChangeType: powerdns.ChangeTypePtr(powerdns.ChangeTypeReplace),
+						Comments:   common.GetOwnershipComments(ownership),
 						Records: []powerdns.Record{
 							{
 								Content:  powerdns.String(primaryName),
@@ -450,8 +561,9 @@ func buildStaticForwardRRSets(networks []sls_common.Network, hardware []sls_comm
 					aliasRRset := powerdns.RRset{
 						Name:       powerdns.String(fmt.Sprintf("%s.%s.%s.", hsnname, networkDomain, *baseDomain)),
 						Type:       powerdns.RRTypePtr(powerdns.RRTypeCNAME),
-						TTL:        powerdns.Uint32(3600),
+						TTL:        powerdns.Uint32(recordTTLs.Get(powerdns.RRTypeCNAME, networkDomain)),
 						ChangeType: powerdns.ChangeTypePtr(powerdns.ChangeTypeReplace),
+						Comments:   common.GetOwnershipComments(ownership),
 						Records: []powerdns.Record{
 							{
 								Content:  powerdns.String(primaryName),
@@ -468,8 +580,9 @@ func buildStaticForwardRRSets(networks []sls_common.Network, hardware []sls_comm
 						aliasRRset := powerdns.RRset{
 							Name:       powerdns.String(fmt.Sprintf("%s.%s.%s.", hostname, networkDomain, *baseDomain)),
 							Type:       powerdns.RRTypePtr(powerdns.RRTypeCNAME),
-							TTL:        powerdns.Uint32(3600),
+							TTL:        powerdns.Uint32(recordTTLs.Get(powerdns.RRTypeCNAME, networkDomain)),
 							ChangeType: powerdns.ChangeTypePtr(powerdns.ChangeTypeReplace),
+							Comments:   common.GetOwnershipComments(ownership),
 							Records: []powerdns.Record{
 								{
 									Content:  powerdns.String(primaryName),
@@ -493,8 +606,9 @@ func buildStaticForwardRRSets(networks []sls_common.Network, hardware []sls_comm
 					aliasRRset := powerdns.RRset{
 						Name:       powerdns.String(fmt.Sprintf("%s.%s.%s.", hostname, networkDomain, *baseDomain)),
 						Type:       powerdns.RRTypePtr(powerdns.RRTypeCNAME),
-						TTL:        powerdns.Uint32(3600),
+						TTL:        powerdns.Uint32(recordTTLs.Get(powerdns.RRTypeCNAME, networkDomain)),
 						ChangeType: powerdns.ChangeTypePtr(powerdns.ChangeTypeReplace),
+						Comments:   common.GetOwnershipComments(ownership),
 						Records: []powerdns.Record{
 							{
 								Content:  powerdns.String(primaryName),
@@ -512,7 +626,8 @@ func buildStaticForwardRRSets(networks []sls_common.Network, hardware []sls_comm
 	return
 }
 
-func buildDynamicReverseRRSets(networks []sls_common.Network, ethernetInterfaces []sm.CompEthInterfaceV2) (dynamicRRSets []powerdns.RRset, err error) {
+func buildDynamicReverseRRSets(networks []sls_common.Network, ipv6Networks []common.NetworkNameCIDRMap,
+	ethernetInterfaces []sm.CompEthInterfaceV2) (dynamicRRSets []powerdns.RRset, err error) {
 
 	// Loop round SMD ethernetInterfaces and then build a list of rrSets
 	for _, ethernetInterface := range ethernetInterfaces {
@@ -521,20 +636,48 @@ func buildDynamicReverseRRSets(networks []sls_common.Network, ethernetInterfaces
 			continue
 		}
 
+		ownership := common.GetHSMEthernetInterfaceOwnership(ethernetInterface.ID)
+
 		for _, ethernetIP := range ethernetInterface.IPAddrs {
 
-			var ip net.IP
-			ip, _, err = net.ParseCIDR(fmt.Sprintf("%s/32", ethernetIP.IPAddr))
-			if err != nil {
+			ip := net.ParseIP(ethernetIP.IPAddr)
+			if ip == nil {
 				logger.Debug("Failed to parse ethernet interface IP address!",
-					zap.Error(err), zap.Any("ethernetInterface", ethernetInterface))
+					zap.Any("ethernetInterface", ethernetInterface))
+				continue
+			}
+
+			// IPv6 networks come from SLS as a whole rather than the network extra properties.
+			if ip.To4() == nil {
+				networkDomain := getNetworkForIP(ip, ipv6Networks)
+				if networkDomain == "" {
+					logger.Debug("buildDynamicReverseRRSets: ethernetInterfaces record does not belong to any SLS network",
+						zap.Any("ethernetInterfaces", ethernetInterface))
+					continue
+				}
+
+				primaryName := fmt.Sprintf("%s.%s.%s.", ethernetInterface.CompID, networkDomain, *baseDomain)
+
+				dynamicRRSets = append(dynamicRRSets, powerdns.RRset{
+					Name:       powerdns.String(common.MakeDomainCanonical(common.GetReverseNameForIP(ip))),
+					Type:       powerdns.RRTypePtr(powerdns.RRTypePTR),
+					TTL:        powerdns.Uint32(recordTTLs.Get(powerdns.RRTypePTR, networkDomain)),
+					ChangeType: powerdns.ChangeTypePtr(powerdns.ChangeTypeReplace),
+					Comments:   common.GetOwnershipComments(ownership),
+					Records: []powerdns.Record{
+						{
+							Content:  powerdns.String(primaryName),
+							Disabled: powerdns.Bool(false),
+						},
+					},
+				})
 				continue
 			}
 
 			// Figure out what SLS network we're in
 			var exists bool = false
 			for _, network := range networks {
-				var networkProperties NetworkExtraProperties
+				var networkProperties sls.NetworkExtraProperties
 				err = mapstructure.Decode(network.ExtraPropertiesRaw, &networkProperties)
 				if err != nil {
 					return
@@ -553,20 +696,21 @@ func buildDynamicReverseRRSets(networks []sls_common.Network, ethernetInterfaces
 						zap.Any("network", network.Name),
 						zap.Any("forwardCIDR", forwardCIDR), zap.Any("IP", ip))
 
-					reverseZoneName := common.GetReverseZoneName(forwardCIDR)
-					cidrParts := strings.Split(ip.String(), ".")
+					reverseZoneName := getReverseZoneName(forwardCIDR)
+					reverseName := getReverseNameForIP(ip, forwardCIDR)
 
 					logger.Debug("buildDynamicReverseRRSets: Calculated reverse zone membership",
 						zap.Any("reverseZoneName", reverseZoneName),
-						zap.Any("reverseName", common.GetReverseName(cidrParts)))
+						zap.Any("reverseName", reverseName))
 
 					primaryName := fmt.Sprintf("%s.%s.%s.", ethernetInterface.CompID, networkDomain, *baseDomain)
 
 					rrsetReverse := powerdns.RRset{
-						Name:       powerdns.String(common.MakeDomainCanonical(common.GetReverseName(cidrParts))),
+						Name:       powerdns.String(common.MakeDomainCanonical(reverseName)),
 						Type:       powerdns.RRTypePtr(powerdns.RRTypePTR),
-						TTL:        powerdns.Uint32(3600),
+						TTL:        powerdns.Uint32(recordTTLs.Get(powerdns.RRTypePTR, networkDomain)),
 						ChangeType: powerdns.ChangeTypePtr(powerdns.ChangeTypeReplace),
+						Comments:   common.GetOwnershipComments(ownership),
 						Records: []powerdns.Record{
 							{
 								Content:  powerdns.String(primaryName),
@@ -590,6 +734,11 @@ func buildDynamicReverseRRSets(networks []sls_common.Network, ethernetInterfaces
 
 func buildStaticReverseRRSets(networks []sls_common.Network,
 	reverseZone *powerdns.Zone) (staticReverseRRSets []powerdns.RRset, err error) {
+	// IPv6 reverse RRsets come from the SLS hardware instead, see buildStaticIPv6RRSets.
+	if reverseZone != nil && reverseZone.Name != nil && common.IsIPv6ReverseName(*reverseZone.Name) {
+		return
+	}
+
 	var forwardCIDRString string
 	forwardCIDRString, err = common.GetForwardCIDRStringForReverseZone(reverseZone)
 	if err != nil {
@@ -599,11 +748,25 @@ func buildStaticReverseRRSets(networks []sls_common.Network,
 	for _, network := range networks {
 		for _, ipRange := range network.IPRanges {
 			var ip net.IP
-			ip, _, err = net.ParseCIDR(ipRange)
+			var cidr *net.IPNet
+			ip, cidr, err = net.ParseCIDR(ipRange)
 			if err != nil {
 				return
 			}
 
+			// RFC 2317 classless zones only hold the PTRs for their own network.
+			if isClassless(cidr) {
+				if common.MakeDomainCanonical(getReverseZoneName(cidr)) == *reverseZone.Name {
+					var classlessRRSets []powerdns.RRset
+					classlessRRSets, err = buildStaticReverseRRSetsForNetwork(network, cidr)
+					if err != nil {
+						return
+					}
+					staticReverseRRSets = append(staticReverseRRSets, classlessRRSets...)
+				}
+				continue
+			}
+
 			/*	Need to discount the last octet as on a system with small subnets multiple SLS networks could map to
 				the	same reverse zone. For example a CAN of 10.101.5.128/26 and a CMN of 10.101.5.0/25 would both map
 				to the same /24 reverse zone of 5.101.10.in-addr.arpa */
@@ -611,40 +774,59 @@ func buildStaticReverseRRSets(networks []sls_common.Network,
 			ip[3] = 0
 
 			if forwardCIDRString == ip.String() {
-				networkDomain := strings.ToLower(network.Name)
-
-				var networkProperties NetworkExtraProperties
-				err = mapstructure.Decode(network.ExtraPropertiesRaw, &networkProperties)
+				var networkRRSets []powerdns.RRset
+				networkRRSets, err = buildStaticReverseRRSetsForNetwork(network, cidr)
 				if err != nil {
 					return
 				}
+				staticReverseRRSets = append(staticReverseRRSets, networkRRSets...)
+			}
+		}
+	}
 
-				for _, subnet := range networkProperties.Subnets {
-					for _, reservation := range subnet.IPReservations {
-						// Avoid bad names.
-						if strings.Contains(reservation.Name, ".") {
-							continue
-						}
+	return
+}
 
-						primaryName := fmt.Sprintf("%s.%s.%s.", reservation.Name, networkDomain, *baseDomain)
-						cidrParts := strings.Split(reservation.IPAddress, ".")
+// buildStaticReverseRRSetsForNetwork builds a PTR for every IP reservation in the network.
+func buildStaticReverseRRSetsForNetwork(network sls_common.Network,
+	cidr *net.IPNet) (staticReverseRRSets []powerdns.RRset, err error) {
+	networkDomain := strings.ToLower(network.Name)
 
-						rrsetReverse := powerdns.RRset{
-							Name:       powerdns.String(common.MakeDomainCanonical(common.GetReverseName(cidrParts))),
-							Type:       powerdns.RRTypePtr(powerdns.RRTypePTR),
-							TTL:        powerdns.Uint32(3600),
-							ChangeType: powerdns.ChangeTypePtr(powerdns.ChangeTypeReplace),
-							Records: []powerdns.Record{
-								{
-									Content:  powerdns.String(primaryName),
-									Disabled: powerdns.Bool(false),
-								},
-							},
-						}
-						staticReverseRRSets = append(staticReverseRRSets, rrsetReverse)
-					}
-				}
+	var networkProperties sls.NetworkExtraProperties
+	err = mapstructure.Decode(network.ExtraPropertiesRaw, &networkProperties)
+	if err != nil {
+		return
+	}
+
+	for _, subnet := range networkProperties.Subnets {
+		for _, reservation := range subnet.IPReservations {
+			// Avoid bad names.
+			if strings.Contains(reservation.Name, ".") {
+				continue
+			}
+
+			ip := net.ParseIP(reservation.IPAddress)
+			if ip == nil {
+				continue
 			}
+
+			primaryName := fmt.Sprintf("%s.%s.%s.", reservation.Name, networkDomain, *baseDomain)
+			ownership := common.GetSLSReservationOwnership(network.Name, subnet.Name, reservation.Name)
+
+			rrsetReverse := powerdns.RRset{
+				Name:       powerdns.String(common.MakeDomainCanonical(getReverseNameForIP(ip, cidr))),
+				Type:       powerdns.RRTypePtr(powerdns.RRTypePTR),
+				TTL:        powerdns.Uint32(recordTTLs.Get(powerdns.RRTypePTR, networkDomain)),
+				ChangeType: powerdns.ChangeTypePtr(powerdns.ChangeTypeReplace),
+				Comments:   common.GetOwnershipComments(ownership),
+				Records: []powerdns.Record{
+					{
+						Content:  powerdns.String(primaryName),
+						Disabled: powerdns.Bool(false),
+					},
+				},
+			}
+			staticReverseRRSets = append(staticReverseRRSets, rrsetReverse)
 		}
 	}
 
@@ -652,8 +834,8 @@ func buildStaticReverseRRSets(networks []sls_common.Network,
 }
 
 func buildDynamicForwardRRsets(hardware []sls_common.GenericHardware, networks []sls_common.Network,
-	ethernetInterfaces []sm.CompEthInterfaceV2) (dynamicRRSets []powerdns.RRset,
-	err error) {
+	ipv6Networks []common.NetworkNameCIDRMap, ethernetInterfaces []sm.CompEthInterfaceV2) (
+	dynamicRRSets []powerdns.RRset, err error) {
 
 	// Start by precomputing network information.
 	var networkNameCIDRMaps []common.NetworkNameCIDRMap
@@ -673,11 +855,10 @@ func buildDynamicForwardRRsets(hardware []sls_common.GenericHardware, networks [
 		}
 	}
 
+	networkNameCIDRMaps = append(networkNameCIDRMaps, ipv6Networks...)
+
 	// Also build an SLS hardware map.
-	slsHardwareMap := make(map[string]sls_common.GenericHardware)
-	for _, device := range hardware {
-		slsHardwareMap[device.Xname] = device
-	}
+	slsHardwareMap := sls.GetHardwareMap(hardware)
 
 	for _, ethernetInterface := range ethernetInterfaces {
 		// Have to ignore entries without IPs or ComponentIDs.
@@ -685,13 +866,15 @@ func buildDynamicForwardRRsets(hardware []sls_common.GenericHardware, networks [
 			continue
 		}
 
+		ownership := common.GetHSMEthernetInterfaceOwnership(ethernetInterface.ID)
+
 		for _, ethernetIP := range ethernetInterface.IPAddrs {
 
 			var belongedNetwork common.NetworkNameCIDRMap
-			ip, _, err := net.ParseCIDR(fmt.Sprintf("%s/32", ethernetIP.IPAddr))
-			if err != nil {
+			ip := net.ParseIP(ethernetIP.IPAddr)
+			if ip == nil {
 				logger.Debug("Failed to parse ethernet interface IP!",
-					zap.Error(err), zap.Any("ethernetInterface", ethernetInterface))
+					zap.Any("ethernetInterface", ethernetInterface))
 				continue
 			}
 
@@ -712,16 +895,17 @@ func buildDynamicForwardRRsets(hardware []sls_common.GenericHardware, networks [
 			// Now we know the network path.
 			networkDomain := strings.ToLower(belongedNetwork.Name)
 
-			// Start by making the core A record.
+			// Start by making the core A (or AAAA) record.
 			primaryName := fmt.Sprintf("%s.%s.%s.", ethernetInterface.CompID, networkDomain, *baseDomain)
 			primaryRRset := powerdns.RRset{
 				Name:       powerdns.String(primaryName),
-				Type:       powerdns.RRTypePtr(powerdns.RRTypeA),
-				TTL:        powerdns.Uint32(3600),
+				Type:       powerdns.RRTypePtr(common.GetAddressRRType(ip)),
+				TTL:        powerdns.Uint32(recordTTLs.Get(common.GetAddressRRType(ip), networkDomain)),
 				ChangeType: powerdns.ChangeTypePtr(powerdns.ChangeTypeReplace),
+				Comments:   common.GetOwnershipComments(ownership),
 				Records: []powerdns.Record{
 					{
-						Content:  powerdns.String(ethernetIP.IPAddr),
+						Content:  powerdns.String(ip.String()),
 						Disabled: powerdns.Bool(false),
 					},
 				},
@@ -748,8 +932,9 @@ func buildDynamicForwardRRsets(hardware []sls_common.GenericHardware, networks [
 				aliasRRset := powerdns.RRset{
 					Name:       powerdns.String(fmt.Sprintf("%s.%s.%s.", alias, networkDomain, *baseDomain)),
 					Type:       powerdns.RRTypePtr(powerdns.RRTypeCNAME),
-					TTL:        powerdns.Uint32(3600),
+					TTL:        powerdns.Uint32(recordTTLs.Get(powerdns.RRTypeCNAME, networkDomain)),
 					ChangeType: powerdns.ChangeTypePtr(powerdns.ChangeTypeReplace),
+					Comments:   common.GetOwnershipComments(ownership),
 					Records: []powerdns.Record{
 						{
 							Content:  powerdns.String(primaryName),
@@ -765,98 +950,247 @@ func buildDynamicForwardRRsets(hardware []sls_common.GenericHardware, networks [
 	return
 }
 
+// isRemovableRRType returns true for the types of RRset the manager generates from SLS and HSM data and is therefore
+// allowed to remove once they are no longer justified.
+func isRemovableRRType(rrType powerdns.RRType) bool {
+	switch rrType {
+	case powerdns.RRTypeA, powerdns.RRTypeAAAA, powerdns.RRTypeCNAME, powerdns.RRTypePTR:
+		return true
+	default:
+		return false
+	}
+}
+
+// isRemovableRRset returns true if the RRset is owned by the manager and of a type it is allowed to remove. Operator
+// defined RRsets can be of any type and are always removable once they have been deleted from the manager.
+func isRemovableRRset(rrSet powerdns.RRset) bool {
+	ownership, owned := common.GetRRsetOwnership(rrSet)
+	if !owned {
+		return false
+	}
+
+	return isRemovableRRType(*rrSet.Type) || ownership.Source == common.OwnerSourceManual ||
+		ownership.Source == common.OwnerSourceStatic
+}
+
+// ownershipNeedsUpdate returns true if the existing RRset is not marked as owned by the manager or was generated from a
+// different source than the desired RRset. Refreshing the last seen time of an otherwise unchanged RRset patches the
+// zone, bumping the serial and notifying the secondaries, so it only happens if an ownership refresh interval is set.
+func ownershipNeedsUpdate(desiredRRset powerdns.RRset, zoneRRset powerdns.RRset) bool {
+	zoneOwnership, owned := common.GetRRsetOwnership(zoneRRset)
+	if !owned {
+		return true
+	}
+
+	desiredOwnership, _ := common.GetRRsetOwnership(desiredRRset)
+	if zoneOwnership.String() != desiredOwnership.String() {
+		return true
+	}
+
+	if *ownershipRefreshInterval <= 0 {
+		return false
+	}
+
+	refreshInterval := time.Duration(*ownershipRefreshInterval) * time.Second
+	return desiredOwnership.LastSeen.Sub(zoneOwnership.LastSeen) > refreshInterval
+}
+
 // trueUpRRSets verifies all of the RRsets for the zone are as they should be.
 // There are a total of 3 possibilities for each RRset:
 //  1. The RRset doesn't exist at all.
 //  2. The RRset exists but the records are not correct.
 //  3. The RRset exists and shouldn't.
-func trueUpRRSets(rrsets []powerdns.RRset, zones []*powerdns.Zone) (didSomething bool) {
-	// Main data structure to keep track of the RRsets we actually need to patch with the zone it should be added to.
-	actionableRRSetMap := make(map[string]*powerdns.RRsets)
-	for _, zone := range zones {
-		actionableRRSetMap[*zone.Name] = &powerdns.RRsets{Sets: []powerdns.RRset{}}
+//
+// Only RRsets owned by the manager are ever considered for case 3 and only then if removeStale is set, which the
+// caller should only do when the desired RRsets are a complete picture of what SLS and HSM justify.
+//
+// Every change is recorded in the plan and for a dry run that is all that happens, nothing is patched.
+func trueUpRRSets(rrsets []powerdns.RRset, zones []*powerdns.Zone, removeStale bool, plan *Plan,
+	dryRun bool) (changedZones []string) {
+	planRRSets(rrsets, zones, removeStale, plan)
+
+	if dryRun {
+		return
+	}
+
+	return patchZones(plan)
+}
+
+// mergeAddressRRSets combines the A and AAAA RRsets a source generated for the same name into a single RRset with every
+// address, e.g. an ethernet interface with more than one IP on a network. Otherwise only one of the addresses would
+// make it into PowerDNS. The merged RRset keeps the ownership of the first one. Addresses for the same name from
+// different sources are a conflict, not something to merge, and are left to resolveConflicts.
+func mergeAddressRRSets(rrSets []powerdns.RRset) (mergedRRSets []powerdns.RRset) {
+	type addressKey struct {
+		common.RRsetKey
+		source string
 	}
+	mergedIndexes := make(map[addressKey]int)
+
+	for _, rrSet := range rrSets {
+		if *rrSet.Type != powerdns.RRTypeA && *rrSet.Type != powerdns.RRTypeAAAA {
+			mergedRRSets = append(mergedRRSets, rrSet)
+			continue
+		}
 
-	// To make this process a lot quicker first build up a map of names to RRsets for O(1) lookups later.
-	zoneRRsetMap := make(map[string]powerdns.RRset)
-	desiredRRSetMap := make(map[string]powerdns.RRset)
+		ownership, _ := common.GetRRsetOwnership(rrSet)
+		key := addressKey{RRsetKey: common.GetRRsetKey(rrSet), source: ownership.Source}
+
+		i, found := mergedIndexes[key]
+		if !found {
+			mergedIndexes[key] = len(mergedRRSets)
+			rrSet.Records = common.SortRecords(rrSet.Records)
+			mergedRRSets = append(mergedRRSets, rrSet)
+			continue
+		}
+
+		records := mergedRRSets[i].Records
+	records:
+		for _, record := range rrSet.Records {
+			for _, existingRecord := range records {
+				if *existingRecord.Content == *record.Content {
+					continue records
+				}
+			}
+			records = append(records, record)
+		}
+		mergedRRSets[i].Records = common.SortRecords(records)
+	}
+
+	return
+}
+
+// getDesiredRRSetMap indexes the desired RRsets by name and type. A name can have both an A and an AAAA RRset but a
+// CNAME can't share its name with anything else, when that happens the RRset that comes later wins.
+func getDesiredRRSetMap(rrsets []powerdns.RRset) map[common.RRsetKey]powerdns.RRset {
+	desiredRRSetMap := make(map[common.RRsetKey]powerdns.RRset)
+	nameTypes := make(map[string]map[powerdns.RRType]bool)
+
+	for _, desiredRRset := range rrsets {
+		name := *desiredRRset.Name
+		if nameTypes[name] == nil {
+			nameTypes[name] = make(map[powerdns.RRType]bool)
+		}
+
+		for existingType := range nameTypes[name] {
+			if (*desiredRRset.Type == powerdns.RRTypeCNAME) != (existingType == powerdns.RRTypeCNAME) {
+				delete(desiredRRSetMap, common.RRsetKey{Name: name, Type: existingType})
+				delete(nameTypes[name], existingType)
+			}
+		}
+
+		nameTypes[name][*desiredRRset.Type] = true
+		desiredRRSetMap[common.GetRRsetKey(desiredRRset)] = desiredRRset
+	}
+
+	return desiredRRSetMap
+}
+
+// planRRSets diffs the desired RRsets against those in the zones and records every create, replace, and, if removeStale
+// is set, delete in the plan.
+func planRRSets(rrsets []powerdns.RRset, zones []*powerdns.Zone, removeStale bool, plan *Plan) {
+	// To make this process a lot quicker first build up a map of zones, names, and types to RRsets for O(1) lookups
+	// later. The zone is part of the key as the apex NS of a zone has the same name as the delegation in its parent.
+	zoneRRsetMap := make(map[string]map[common.RRsetKey]powerdns.RRset)
+	desiredRRSetMap := getDesiredRRSetMap(rrsets)
 
 	var zoneNames []string
 	for _, zone := range zones {
 		zoneNames = append(zoneNames, *zone.Name)
 
+		zoneRRsetMap[*zone.Name] = make(map[common.RRsetKey]powerdns.RRset)
 		for _, zoneRRset := range zone.RRsets {
-			zoneRRsetMap[*zoneRRset.Name] = zoneRRset
+			zoneRRsetMap[*zone.Name][common.GetRRsetKey(zoneRRset)] = zoneRRset
 		}
 	}
-	for _, desiredRRset := range rrsets {
-		desiredRRSetMap[*desiredRRset.Name] = desiredRRset
-	}
 
 	for _, desiredRRset := range desiredRRSetMap {
-		zoneRRset, found := zoneRRsetMap[*desiredRRset.Name]
-
-		patchLogger := logger.With(zap.Any("desiredRRset", desiredRRset),
-			zap.Any("zoneRRset", zoneRRset))
-
 		// Need to identity which zone this record belongs to.
 		zoneName := common.GetZoneForRRSet(desiredRRset, zones)
 		if zoneName == nil {
-			patchLogger.Error("Desired RRSet did not match any master zones!",
-				zap.Any("zoneNames", zoneNames))
+			logger.Error("Desired RRSet did not match any master zones!",
+				zap.Any("desiredRRset", desiredRRset), zap.Any("zoneNames", zoneNames))
 			continue
 		}
 
-		zoneSets := &actionableRRSetMap[*zoneName].Sets
+		zoneRRset, found := zoneRRsetMap[*zoneName][common.GetRRsetKey(desiredRRset)]
+
+		patchLogger := logger.With(zap.Any("desiredRRset", desiredRRset),
+			zap.Any("zoneRRset", zoneRRset))
+
+		zonePlan := plan.getZonePlan(*zoneName)
 
 		if found {
 			// Case 2 - is the RRSet correct?
 			if !common.RRsetsEqual(desiredRRset, zoneRRset) {
-				*zoneSets = append(*zoneSets, desiredRRset)
+				zonePlan.Replaces = append(zonePlan.Replaces, desiredRRset)
 				patchLogger.Info("RRset exists but is not ideal configuration, adding to patch list.")
+			} else if ownershipNeedsUpdate(desiredRRset, zoneRRset) {
+				// Records created before ownership was tracked need to be adopted otherwise they could never be
+				// cleaned up once they go stale. Owned records can also have their last seen time refreshed.
+				zonePlan.Replaces = append(zonePlan.Replaces, desiredRRset)
+				patchLogger.Info("RRset is correct but ownership needs updating, adding to patch list.")
 			} else {
 				logger.Debug("RRset already at desired config", zap.Any("zoneRRset", zoneRRset))
 			}
 		} else {
 			// Case 1 - not found, add it.
-			*zoneSets = append(*zoneSets, desiredRRset)
+			zonePlan.Creates = append(zonePlan.Creates, desiredRRset)
 			patchLogger.Info("RRset does not exist, adding to patch list.")
 		}
 	}
 
 	// Case 3 - should this exist?
-	for _, zoneRRset := range zoneRRsetMap {
-		_, found := desiredRRSetMap[*zoneRRset.Name]
+	if removeStale {
+		for _, zone := range zones {
+			for _, zoneRRset := range zone.RRsets {
+				if !isRemovableRRset(zoneRRset) {
+					continue
+				}
 
-		if !found && zoneRRset.Type == powerdns.RRTypePtr(powerdns.RRTypeNS) {
-			patchLogger := logger.With(zap.Any("zoneRRset", zoneRRset))
+				if _, found := desiredRRSetMap[common.GetRRsetKey(zoneRRset)]; found {
+					continue
+				}
 
-			// Need to identity which zone this record belongs to.
-			zoneName := common.GetZoneForRRSet(zoneRRset, zones)
-			if zoneName == nil {
-				patchLogger.Error("Desired RRSet did not match any master zones!", zap.Any("zones", zones))
-				continue
+				zoneRRset.ChangeType = powerdns.ChangeTypePtr(powerdns.ChangeTypeDelete)
+				zonePlan := plan.getZonePlan(*zone.Name)
+				zonePlan.Deletes = append(zonePlan.Deletes, zoneRRset)
+				logger.Info("RRset is stale and needs to be removed, adding to patch list.",
+					zap.Any("zoneRRset", zoneRRset))
 			}
-
-			zoneSets := actionableRRSetMap[*zoneName].Sets
-
-			zoneRRset.ChangeType = powerdns.ChangeTypePtr(powerdns.ChangeTypeDelete)
-			zoneSets = append(zoneSets, zoneRRset)
-			patchLogger.Info("RRset needs to be removed, adding to patch list.")
 		}
 	}
 
-	for zone, rrSets := range actionableRRSetMap {
+}
+
+// patchZones sends every RRset change in the plan to PowerDNS, one patch per zone. Any zone that fails to patch has the
+// error recorded in its plan. The zones that changed, either because they were patched or because they were created or
+// updated earlier in the run, are returned.
+func patchZones(plan *Plan) (changedZones []string) {
+	for _, zonePlan := range plan.Zones {
+		zone := zonePlan.Name
 		zoneLogger := logger.With(zap.String("zone", zone))
 
-		if len(rrSets.Sets) > 0 {
+		if !zonePlan.HasRRsetChanges() {
+			if (zonePlan.CreateZone || zonePlan.ReplaceZone || len(zonePlan.DNSSECChanges) > 0) &&
+				zonePlan.Error == "" {
+				changedZones = append(changedZones, zone)
+			}
+		} else {
 			// Do all the patching (which is additions, changes, and deletes) in one API call...pretty cool.
-			err := pdns.Records.Patch(zone, rrSets)
+			err := pdns.Records.Patch(zone, zonePlan.GetPatchRRsets())
+			recordPowerDNSResult(err)
 			if err != nil {
 				zoneLogger.Error("Failed to patch RRsets!", zap.Error(err), zap.Any("zone", zone))
+				zonePlan.Error = fmt.Sprintf("failed to patch RRsets: %s", err)
+				managerMetrics.PatchFailures.WithLabelValues(zone).Inc()
 			} else {
 				zoneLogger.Info("Patched RRSets")
-				didSomething = true
+				changedZones = append(changedZones, zone)
+
+				managerMetrics.RRsetsPatched.WithLabelValues(zone, "create").Add(float64(len(zonePlan.Creates)))
+				managerMetrics.RRsetsPatched.WithLabelValues(zone, "replace").Add(float64(len(zonePlan.Replaces)))
+				managerMetrics.RRsetsPatched.WithLabelValues(zone, "delete").Add(float64(len(zonePlan.Deletes)))
 			}
 		}
 	}
@@ -864,164 +1198,438 @@ func trueUpRRSets(rrsets []powerdns.RRset, zones []*powerdns.Zone) (didSomething
 	return
 }
 
-func trueUpDNS() {
-	logger.Info("Running true up loop at interval.", zap.Int("trueUpLoopInterval", *trueUpSleepInterval))
-
-	defer WaitGroup.Done()
+// buildDesiredRRSets runs every builder and returns all the RRsets SLS and HSM justify. If any of the builders fail the
+// RRsets are incomplete and desiredStateComplete is false.
+func buildDesiredRRSets(networks []sls_common.Network, hardware []sls_common.GenericHardware,
+	ipv6Networks []common.NetworkNameCIDRMap, ethernetInterfaces []sm.CompEthInterfaceV2,
+	stateComponents base.ComponentArray, reverseZones []*powerdns.Zone) (finalRRSet []powerdns.RRset,
+	desiredStateComplete bool) {
+	// Build the RRSets, static SLS records first then the HSM dynamic records.
+	// The PowerDNS API will not permit the submission of duplicates so drop entries
+	// that already exist in finalRRSet before passing to trueUpRRSets() to make the
+	// API call.
+	// If any of the builders fail then the desired state is incomplete and it isn't safe to remove anything.
+	desiredStateComplete = true
+
+	staticRRSets, buildErr := buildStaticForwardRRSets(networks, hardware, stateComponents)
+	if buildErr != nil {
+		logger.Error("Failed to build static RRsets!", zap.Error(buildErr))
+		desiredStateComplete = false
+	}
+	finalRRSet = append(finalRRSet, staticRRSets...)
 
-	var masterNameserver common.Nameserver
-	var slaveNameservers []common.Nameserver
+	staticIPv6RRSets, buildErr := buildStaticIPv6RRSets(hardware, ipv6Networks)
+	if buildErr != nil {
+		logger.Error("Failed to build static IPv6 RRsets!", zap.Error(buildErr))
+		desiredStateComplete = false
+	}
+	finalRRSet = append(finalRRSet, staticIPv6RRSets...)
 
-	if *masterServer != "" {
-		masterNameserverSplit := strings.Split(*masterServer, "/")
-		if len(masterNameserverSplit) != 2 {
-			logger.Fatal("Master nameserver does not have name/IP format!",
-				zap.String("masterServer", *masterServer))
-		}
-		masterNameserver = common.Nameserver{
-			FQDN: fmt.Sprintf("%s.%s", masterNameserverSplit[0], *baseDomain),
-			IP:   masterNameserverSplit[1],
+	for _, reverseZone := range reverseZones {
+		staticRRSetsReverse, buildErr := buildStaticReverseRRSets(networks, reverseZone)
+		if buildErr != nil {
+			logger.Error("Failed to build reverse zone RRsets!",
+				zap.Error(buildErr), zap.Any("reverseZone", reverseZone))
+			desiredStateComplete = false
 		}
+
+		// Add all these records to the final RR set.
+		finalRRSet = append(finalRRSet, staticRRSetsReverse...)
 	}
 
-	if *slaveServers != "" {
-		for _, slaveServer := range strings.Split(*slaveServers, ",") {
-			nameserverSplit := strings.Split(slaveServer, "/")
-			if len(nameserverSplit) != 2 {
-				logger.Fatal("Slave nameserver does not have FQDN/IP format!",
-					zap.String("slaveServer", slaveServer))
-			}
-			slaveNameserver := common.Nameserver{
-				FQDN: common.MakeDomainCanonical(nameserverSplit[0]),
-				IP:   nameserverSplit[1],
-			}
+	delegationRRSets, buildErr := buildClasslessDelegationRRSets(networks)
+	if buildErr != nil {
+		logger.Error("Failed to build classless reverse zone delegation RRsets!", zap.Error(buildErr))
+		desiredStateComplete = false
+	}
+	finalRRSet = append(finalRRSet, delegationRRSets...)
 
-			slaveNameservers = append(slaveNameservers, slaveNameserver)
-		}
+	dynamicRRSets, buildErr := buildDynamicForwardRRsets(hardware, networks, ipv6Networks, ethernetInterfaces)
+	if buildErr != nil {
+		logger.Error("Failed to build dynamic RRsets!", zap.Error(buildErr))
+		desiredStateComplete = false
 	}
 
-	for Running {
-		// This block is at the very top of this loop so that we can `continue` our way to the next iteration if there
-		// is an error and not just blow past the sleep block.
-		select {
-		case <-trueUpShutdown:
-			return
-		case <-trueUpRunNow: // For those impatient types.
-		case <-time.After(time.Duration(*trueUpSleepInterval) * time.Second):
-			logger.Debug("Running true up loop.")
+	for _, RRSet := range dynamicRRSets {
+		if !common.RRsetsContains(finalRRSet, RRSet) {
+			logger.Debug("Adding RRset", zap.Any("rrSet", RRSet))
+			finalRRSet = append(finalRRSet, RRSet)
+		} else {
+			logger.Debug("Refusing to add duplicate RRset", zap.Any("rrSet", RRSet))
 		}
 
-		trueUpMtx.Lock()
-		trueUpInProgress = true
-		trueUpMtx.Unlock()
+	}
 
-		var allMasterZones common.PowerDNSZones
-		var finalRRSet []powerdns.RRset
+	dynamicRRSetsReverse, buildErr := buildDynamicReverseRRSets(networks, ipv6Networks, ethernetInterfaces)
+	if buildErr != nil {
+		logger.Error("Failed to build reverse zone RRsets!",
+			zap.Error(buildErr))
+		desiredStateComplete = false
+	}
 
-		networks, err := getSLSNetworks()
-		if err != nil {
-			logger.Error("Failed to get networks from SLS!", zap.Error(err))
-			continue
-		}
-		hardware, err := getSLSHardware()
-		if err != nil {
-			logger.Error("Failed to get hardware from SLS!", zap.Error(err))
-			continue
-		}
-		ethernetInterfaces, err := getHSMEthernetInterfaces()
-		if err != nil {
-			logger.Error("Failed to get ethernet interfaces from HSM!", zap.Error(err))
-			continue
+	for _, RRSet := range dynamicRRSetsReverse {
+		if !common.RRsetsContains(finalRRSet, RRSet) {
+			logger.Debug("Adding RRset", zap.Any("rrSet", RRSet))
+			finalRRSet = append(finalRRSet, RRSet)
+		} else {
+			logger.Debug("Refusing to add duplicate RRset", zap.Any("rrSet", RRSet))
 		}
 
-		// Retrieve smd/v2/State/Components records. Necessary because the UAN NID is dynamically assigned by SMD.
-		stateComponents, err := getHSMNodeState()
-		if err != nil {
-			logger.Error("Failed to get component state from HSM!", zap.Error(err))
-			continue
-		}
+	}
 
-		// Build/get all necessary master zones.
-		masterZones := trueUpMasterZones(*baseDomain, networks, masterNameserver, slaveNameservers)
+	finalRRSet = mergeAddressRRSets(finalRRSet)
 
-		// True up reverse zones.
-		reverseZones, err := trueUpReverseZones(networks, masterNameserver, slaveNameservers)
-		if err != nil {
-			logger.Error("Failed to true up reverse zones!", zap.Error(err))
-		}
+	return
+}
 
-		// Build a list of all master zones both forward and reverse.
-		allMasterZones = append(allMasterZones, masterZones...)
-		allMasterZones = append(allMasterZones, reverseZones...)
+// runTrueUp computes the full desired state from SLS and HSM, diffs it against PowerDNS, and, unless this is a dry run,
+// makes PowerDNS match. The returned plan has every zone and RRset change that was (or for a dry run would be) made.
+//
+// The job, if there is one, is kept up to date with the phase of the run.
+func runTrueUp(dryRun bool, job *Job) (plan *Plan, err error) {
+	plan = NewPlan(dryRun)
 
-		// Build the RRSets, static SLS records first then the HSM dynamic records.
-		// The PowerDNS API will not permit the submission of duplicates so drop entries
-		// that already exist in finalRRSet before passing to trueUpRRSets() to make the
-		// API call.
-		staticRRSets, err := buildStaticForwardRRSets(networks, hardware, stateComponents)
-		if err != nil {
-			logger.Error("Failed to build static RRsets!", zap.Error(err))
+	job.setPhase(JobPhaseFetching)
+
+	networks, err := getSLSNetworks()
+	if err != nil {
+		err = fmt.Errorf("failed to get networks from SLS: %w", err)
+		return
+	}
+	hardware, err := getSLSHardware()
+	if err != nil {
+		err = fmt.Errorf("failed to get hardware from SLS: %w", err)
+		return
+	}
+	ethernetInterfaces, err := getHSMEthernetInterfaces()
+	if err != nil {
+		err = fmt.Errorf("failed to get ethernet interfaces from HSM: %w", err)
+		return
+	}
+
+	// Retrieve smd/v2/State/Components records. Necessary because the UAN NID is dynamically assigned by SMD.
+	stateComponents, err := getHSMNodeState()
+	if err != nil {
+		err = fmt.Errorf("failed to get component state from HSM: %w", err)
+		return
+	}
+
+	// Without the operator defined records it isn't safe to carry on as they could be overwritten or removed.
+	staticRecords, err := loadStaticRecords(*staticRecordsFile)
+	if err != nil {
+		return
+	}
+	manualRecords, err := getManualRecords()
+	if err != nil {
+		return
+	}
+
+	ipv6Networks := getIPv6Networks(networks, hardware)
+
+	// Build/get all necessary master zones. Incremental runs use the zones as they were left by the last run instead.
+	job.setPhase(JobPhaseZones)
+	networksFingerprint := getNetworksFingerprint(networks, staticRecords.Zones)
+	incremental := *incrementalTrueUp && !dryRun && !stateCache.needsFullResync(networksFingerprint)
+
+	var allMasterZones common.PowerDNSZones
+	var reverseZones []*powerdns.Zone
+	if incremental {
+		logger.Debug("Running incremental true up from cached state.")
+		allMasterZones = stateCache.zones
+		reverseZones = stateCache.reverseZones
+	} else {
+		allMasterZones, reverseZones = trueUpZones(networks, ipv6Networks, staticRecords.Zones, plan, dryRun,
+			true)
+	}
+	err = staticRecords.checkZones(allMasterZones)
+	if err != nil {
+		return
+	}
+
+	// Keys are rolled over on a schedule so this happens every run, not just when the zones are trued up.
+	trueUpDNSSEC(allMasterZones, plan, dryRun)
+
+	// The upstream data is still fetched every run but if none of it changed there is nothing to build or diff. Any
+	// DNSSEC change still needs the rest of the run to patch and notify the zone.
+	inputsFingerprint := getFingerprint([]interface{}{hardware, ethernetInterfaces, stateComponents, staticRecords,
+		manualRecords})
+	if incremental && !plan.hasChanges() && stateCache.isUnchanged(inputsFingerprint) {
+		logger.Debug("Nothing changed in SLS or HSM since the last run, skipping the incremental true up.")
+		plan.sortZones()
+		return
+	}
+
+	job.setPhase(JobPhaseBuilding)
+	finalRRSet, desiredStateComplete := buildDesiredRRSets(networks, hardware, ipv6Networks, ethernetInterfaces,
+		stateComponents, reverseZones)
+	finalRRSet = resolveConflicts(finalRRSet, plan)
+	finalRRSet = mergeOverrideRRSets(finalRRSet, staticRecords.getRRSets(), plan)
+	finalRRSet = mergeOverrideRRSets(finalRRSet, getManualRRSets(manualRecords), plan)
+
+	// At this point we have computed every correct RRSet necessary. Now the only task is to add the ones that are
+	// missing and remove the ones that shouldn't be there.
+	removeStale := *removeStaleRecords && desiredStateComplete
+	if *removeStaleRecords && !desiredStateComplete {
+		logger.Warn("Desired state is incomplete, not removing any stale RRsets this run.")
+	}
+
+	// Force a sync to any slave servers if we did something.
+	job.setPhase(JobPhaseReconciling)
+	var changedZones []string
+	if incremental {
+		// Only what changed since the last run needs diffing against the cached zones.
+		changed, removed := stateCache.diffDesired(finalRRSet)
+		planRRSets(changed, allMasterZones, false, plan)
+		if removeStale {
+			planRemovedRRSets(removed, allMasterZones, plan)
 		}
-		finalRRSet = append(finalRRSet, staticRRSets...)
+		changedZones = patchZones(plan)
+	} else {
+		changedZones = trueUpRRSets(finalRRSet, allMasterZones, removeStale, plan, dryRun)
+	}
 
-		for _, reverseZone := range reverseZones {
-			staticRRSetsReverse, err := buildStaticReverseRRSets(networks, reverseZone)
-			if err != nil {
-				logger.Error("Failed to build reverse zone RRsets!",
-					zap.Error(err), zap.Any("reverseZone", reverseZone))
-			}
+	if len(changedZones) > 0 {
+		job.setPhase(JobPhaseNotifying)
+		notifySlaveServers(changedZones)
+	}
 
-			// Add all these records to the final RR set.
-			finalRRSet = append(finalRRSet, staticRRSetsReverse...)
+	if *incrementalTrueUp && !dryRun {
+		if desiredStateComplete {
+			stateCache.update(networksFingerprint, inputsFingerprint, finalRRSet, allMasterZones, reverseZones,
+				plan, !incremental)
+		} else {
+			stateCache.invalidate()
 		}
+	}
 
-		dynamicRRSets, err := buildDynamicForwardRRsets(hardware, networks, ethernetInterfaces)
-		if err != nil {
-			logger.Error("Failed to build dynamic RRsets!", zap.Error(err))
+	// A dry run from the plan endpoint shouldn't replace what the last real run found.
+	if !dryRun {
+		recordConflicts(plan)
+	}
+	plan.sortZones()
+
+	return
+}
+
+// runComponentTrueUp is a cut down true up for only the components in the job, used when HSM tells us their state has
+// changed. Only the dynamic RRsets for those components are built and, as the desired state is nowhere near complete,
+// nothing is ever removed. That is left to the next full run.
+func runComponentTrueUp(dryRun bool, job *Job) (plan *Plan, err error) {
+	plan = NewPlan(dryRun)
+
+	job.setPhase(JobPhaseFetching)
+
+	var finalRRSet []powerdns.RRset
+
+	networks, err := getSLSNetworks()
+	if err != nil {
+		err = fmt.Errorf("failed to get networks from SLS: %w", err)
+		return
+	}
+	hardware, err := getSLSHardware()
+	if err != nil {
+		err = fmt.Errorf("failed to get hardware from SLS: %w", err)
+		return
+	}
+	ethernetInterfaces, err := getHSMEthernetInterfaces(job.Components...)
+	if err != nil {
+		err = fmt.Errorf("failed to get ethernet interfaces from HSM: %w", err)
+		return
+	}
+	staticRecords, err := loadStaticRecords(*staticRecordsFile)
+	if err != nil {
+		return
+	}
+	manualRecords, err := getManualRecords()
+	if err != nil {
+		return
+	}
+
+	ipv6Networks := getIPv6Networks(networks, hardware)
+
+	// The zones as the last run left them will do unless there could be new ones, then they are all fetched.
+	job.setPhase(JobPhaseZones)
+	var allMasterZones common.PowerDNSZones
+	if *incrementalTrueUp && !dryRun && stateCache.hasZones(getNetworksFingerprint(networks, staticRecords.Zones)) {
+		logger.Debug("Running component true up against cached zones.")
+		allMasterZones = stateCache.zones
+	} else {
+		allMasterZones, _ = trueUpZones(networks, ipv6Networks, staticRecords.Zones, plan, dryRun, false)
+	}
+	err = staticRecords.checkZones(allMasterZones)
+	if err != nil {
+		return
+	}
+
+	job.setPhase(JobPhaseBuilding)
+	dynamicRRSets, buildErr := buildDynamicForwardRRsets(hardware, networks, ipv6Networks, ethernetInterfaces)
+	if buildErr != nil {
+		logger.Error("Failed to build dynamic RRsets!", zap.Error(buildErr))
+	}
+	finalRRSet = append(finalRRSet, dynamicRRSets...)
+
+	dynamicRRSetsReverse, buildErr := buildDynamicReverseRRSets(networks, ipv6Networks, ethernetInterfaces)
+	if buildErr != nil {
+		logger.Error("Failed to build reverse zone RRsets!", zap.Error(buildErr))
+	}
+
+	for _, RRSet := range dynamicRRSetsReverse {
+		if !common.RRsetsContains(finalRRSet, RRSet) {
+			finalRRSet = append(finalRRSet, RRSet)
 		}
+	}
+	finalRRSet = resolveComponentConflicts(mergeAddressRRSets(finalRRSet), allMasterZones, plan)
+	finalRRSet = mergeOverrideRRSets(finalRRSet, staticRecords.getRRSets(), plan)
+	finalRRSet = mergeOverrideRRSets(finalRRSet, getManualRRSets(manualRecords), plan)
+
+	job.setPhase(JobPhaseReconciling)
+	changedZones := trueUpRRSets(finalRRSet, allMasterZones, false, plan, dryRun)
+	if len(changedZones) > 0 {
+		job.setPhase(JobPhaseNotifying)
+		notifySlaveServers(changedZones)
+	}
 
-		for _, RRSet := range dynamicRRSets {
-			if !common.RRsetsContains(finalRRSet, RRSet) {
-				logger.Debug("Adding RRset", zap.Any("rrSet", RRSet))
-				finalRRSet = append(finalRRSet, RRSet)
-			} else {
-				logger.Debug("Refusing to add duplicate RRset", zap.Any("rrSet", RRSet))
-			}
+	// Keep the cached zones in step with what was just changed.
+	if !dryRun {
+		stateCache.applyPlan(plan)
+	}
+
+	plan.sortZones()
+
+	return
+}
 
+// trueUpZones gets, or creates, every forward and reverse master zone. The reverse zones are also returned on their own
+// as the static reverse RRsets are built per zone.
+func trueUpZones(networks []sls_common.Network, ipv6Networks []common.NetworkNameCIDRMap, staticZoneNames []string,
+	plan *Plan, dryRun bool, handleOrphans bool) (allMasterZones common.PowerDNSZones,
+	reverseZones []*powerdns.Zone) {
+	masterZones := trueUpMasterZones(*baseDomain, networks, staticZoneNames, masterNameserver, slaveNameservers, plan,
+		dryRun)
+
+	// True up reverse zones.
+	reverseZones, reverseErr := trueUpReverseZones(networks, ipv6Networks, masterNameserver, slaveNameservers, plan,
+		dryRun)
+	if reverseErr != nil {
+		logger.Error("Failed to true up reverse zones!", zap.Error(reverseErr))
+	}
+
+	// Only when every zone has been accounted for is it safe to decide which ones are orphaned.
+	if handleOrphans && reverseErr == nil {
+		orphanErr := trueUpOrphanedZones(len(networks), plan, dryRun)
+		if orphanErr != nil {
+			logger.Error("Failed to true up orphaned zones!", zap.Error(orphanErr))
 		}
+	}
 
-		dynamicRRSetsReverse, err := buildDynamicReverseRRSets(networks, ethernetInterfaces)
-		if err != nil {
-			logger.Error("Failed to build reverse zone RRsets!",
-				zap.Error(err))
+	// Build a list of all master zones both forward and reverse.
+	allMasterZones = append(allMasterZones, masterZones...)
+	allMasterZones = append(allMasterZones, reverseZones...)
+
+	return
+}
+
+// isNotifyZone returns true if slave servers should be sent a DNS NOTIFY for the zone, that is every zone unless the
+// notify list has been given.
+func isNotifyZone(zoneName string) bool {
+	if len(notifyZonesArray) == 0 {
+		return true
+	}
+
+	return common.SliceContains(strings.TrimSuffix(zoneName, "."), notifyZonesArray) ||
+		common.SliceContains(common.MakeDomainCanonical(zoneName), notifyZonesArray)
+}
+
+// notifySlaveServers forces a sync of the given zones to any slave servers. Only zones in the notify list are notified
+// and each NOTIFY is retried, backing off between attempts, before giving up until the zone next changes.
+func notifySlaveServers(zoneNames []string) {
+	for _, zoneName := range zoneNames {
+		notifyLogger := logger.With(zap.String("masterZone.name", zoneName))
+
+		if !isNotifyZone(zoneName) {
+			notifyLogger.Debug("Zone not in notify list, not notifying slave server(s).")
+			continue
 		}
 
-		for _, RRSet := range dynamicRRSetsReverse {
-			if !common.RRsetsContains(finalRRSet, RRSet) {
-				logger.Debug("Adding RRset", zap.Any("rrSet", RRSet))
-				finalRRSet = append(finalRRSet, RRSet)
-			} else {
-				logger.Debug("Refusing to add duplicate RRset", zap.Any("rrSet", RRSet))
+		backoff := time.Duration(*notifyRetryInterval) * time.Second
+		for attempt := 0; ; attempt++ {
+			result, err := pdns.Zones.Notify(zoneName)
+			recordPowerDNSResult(err)
+
+			if err == nil {
+				notifyLogger.Info("Notified slave server(s) for zone", zap.Any("result", result))
+				break
+			}
+
+			managerMetrics.NotifyFailures.WithLabelValues(zoneName).Inc()
+			if attempt >= *notifyRetries {
+				notifyLogger.Error("Failed to notify slave server(s) for zone, giving up!", zap.Error(err),
+					zap.Int("attempts", attempt+1))
+				break
 			}
 
+			notifyLogger.Warn("Failed to notify slave server(s) for zone, retrying.", zap.Error(err),
+				zap.Duration("backoff", backoff))
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			backoff *= 2
 		}
+	}
+}
 
-		// At this point we have computed every correct RRSet necessary. Now the only task is to add the ones that are
-		// missing and remove the ones that shouldn't be there.
-		// TODO: Add the remove entries.
+func trueUpDNS() {
+	logger.Info("Running true up loop at interval.", zap.Int("trueUpLoopInterval", *trueUpSleepInterval))
 
-		// Force a sync to any slave servers if we did something.
-		if trueUpRRSets(finalRRSet, allMasterZones) {
-			for _, masterZone := range allMasterZones {
-				result, err := pdns.Zones.Notify(*masterZone.Name)
+	defer WaitGroup.Done()
 
-				notifyLogger := logger.With(zap.String("masterZone.name", *masterZone.Name))
-				if err != nil {
-					notifyLogger.Error("Failed to notify slave server(s) for zone!", zap.Error(err))
-				} else {
-					notifyLogger.Info("Notified slave server(s) for zone", zap.Any("result", result))
-				}
+	for Running {
+		// This block is at the very top of this loop so that we can `continue` our way to the next iteration if there
+		// is an error and not just blow past the sleep block.
+		var job *Job
+		select {
+		case <-trueUpShutdown:
+			return
+		case job = <-trueUpRunNow: // For those impatient types.
+		case <-trueUpSCNRunNow:
+			componentIDs := takeSCNComponents()
+			if len(componentIDs) == 0 {
+				// Already handled by a previous run.
+				continue
 			}
+			job = newJob(JobTriggerSCN, componentIDs...)
+		case <-time.After(time.Duration(*trueUpSleepInterval) * time.Second):
+			logger.Debug("Running true up loop.")
+			job = newJob(JobTriggerTimer)
+		}
+
+		trueUpMtx.Lock()
+		trueUpInProgress = true
+		trueUpMtx.Unlock()
+
+		jobLogger := logger.With(zap.String("job.id", job.ID), zap.String("job.trigger", string(job.Trigger)))
+
+		start := time.Now()
+		var plan *Plan
+		var err error
+		trueUpRunMtx.Lock()
+		if job.Trigger == JobTriggerSCN {
+			plan, err = runComponentTrueUp(*dryRun, job)
+		} else {
+			plan, err = runTrueUp(*dryRun, job)
+		}
+		trueUpRunMtx.Unlock()
+		if err != nil {
+			jobLogger.Error("Failed to true up DNS!", zap.Error(err))
+		} else if *dryRun {
+			jobLogger.Info("Dry run, not making any changes.", zap.Any("plan", plan))
+		}
+		phase := job.finish(plan, err)
+		managerMetrics.ObserveTrueUp(start, phase == JobPhaseSucceeded)
+		if phase == JobPhaseSucceeded {
+			recordTrueUpSucceeded()
 		}
 
 		trueUpMtx.Lock()
@@ -0,0 +1,111 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Cray-HPE/cray-powerdns-manager/internal/common"
+	"github.com/joeig/go-powerdns/v2"
+)
+
+func TestMergeAddressRRSets(t *testing.T) {
+	sls := common.GetSLSHardwareOwnership("x3000c0s2b0n0")
+	hsm := common.GetHSMEthernetInterfaceOwnership("a4bf0138ee65")
+	otherHSM := common.GetHSMEthernetInterfaceOwnership("a4bf0138ee66")
+
+	tests := []struct {
+		name   string
+		rrSets []powerdns.RRset
+		want   map[string][]string
+	}{
+		{
+			name: "addresses from one interface are merged",
+			rrSets: []powerdns.RRset{
+				newTestRRset("node.nmn.example.com.", powerdns.RRTypeA, hsm, "10.252.0.21"),
+				newTestRRset("node.nmn.example.com.", powerdns.RRTypeA, hsm, "10.252.0.20"),
+			},
+			want: map[string][]string{"node.nmn.example.com. A": {"10.252.0.20", "10.252.0.21"}},
+		},
+		{
+			name: "addresses from interfaces of the same source are merged",
+			rrSets: []powerdns.RRset{
+				newTestRRset("node.nmn.example.com.", powerdns.RRTypeA, hsm, "10.252.0.20"),
+				newTestRRset("node.nmn.example.com.", powerdns.RRTypeA, otherHSM, "10.252.0.21"),
+			},
+			want: map[string][]string{"node.nmn.example.com. A": {"10.252.0.20", "10.252.0.21"}},
+		},
+		{
+			name: "duplicate addresses are dropped",
+			rrSets: []powerdns.RRset{
+				newTestRRset("node.nmn.example.com.", powerdns.RRTypeA, hsm, "10.252.0.20"),
+				newTestRRset("node.nmn.example.com.", powerdns.RRTypeA, hsm, "10.252.0.20"),
+			},
+			want: map[string][]string{"node.nmn.example.com. A": {"10.252.0.20"}},
+		},
+		{
+			name: "A and AAAA stay separate",
+			rrSets: []powerdns.RRset{
+				newTestRRset("node.nmn.example.com.", powerdns.RRTypeA, hsm, "10.252.0.20"),
+				newTestRRset("node.nmn.example.com.", powerdns.RRTypeAAAA, hsm, "fd00::20"),
+			},
+			want: map[string][]string{
+				"node.nmn.example.com. A":    {"10.252.0.20"},
+				"node.nmn.example.com. AAAA": {"fd00::20"},
+			},
+		},
+		{
+			name: "PTRs are never merged",
+			rrSets: []powerdns.RRset{
+				newTestRRset("20.0.252.10.in-addr.arpa.", powerdns.RRTypePTR, hsm, "a.nmn.example.com."),
+				newTestRRset("20.0.252.10.in-addr.arpa.", powerdns.RRTypePTR, hsm, "b.nmn.example.com."),
+			},
+			want: map[string][]string{
+				"20.0.252.10.in-addr.arpa. PTR": {"a.nmn.example.com.", "b.nmn.example.com."},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := getTestRRsetContents(mergeAddressRRSets(test.rrSets))
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("mergeAddressRRSets() = %v, want %v", got, test.want)
+			}
+		})
+	}
+
+	t.Run("different sources are left for conflict resolution", func(t *testing.T) {
+		merged := mergeAddressRRSets([]powerdns.RRset{
+			newTestRRset("node.nmn.example.com.", powerdns.RRTypeA, sls, "10.252.0.20"),
+			newTestRRset("node.nmn.example.com.", powerdns.RRTypeA, hsm, "10.252.0.21"),
+		})
+		if len(merged) != 2 {
+			t.Errorf("mergeAddressRRSets() returned %d RRsets, want 2", len(merged))
+		}
+	})
+}
@@ -0,0 +1,220 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/joeig/go-powerdns/v2"
+)
+
+// The largest key file that will be read, anything bigger certainly isn't a key.
+const maxKeyFileSize = 64 * 1024
+
+// The TSIG algorithms PowerDNS supports.
+var tsigAlgorithms = map[string]bool{
+	"hmac-md5":    true,
+	"hmac-sha1":   true,
+	"hmac-sha224": true,
+	"hmac-sha256": true,
+	"hmac-sha384": true,
+	"hmac-sha512": true,
+}
+
+// The fields each DNSSEC algorithm needs in a BIND private key file, keyed by algorithm number.
+var rsaPrivateKeyFields = []string{"Modulus", "PublicExponent", "PrivateExponent", "Prime1", "Prime2", "Exponent1",
+	"Exponent2", "Coefficient"}
+var dnssecAlgorithmPrivateKeyFields = map[int][]string{
+	5:  rsaPrivateKeyFields, // RSASHA1
+	7:  rsaPrivateKeyFields, // RSASHA1-NSEC3-SHA1
+	8:  rsaPrivateKeyFields, // RSASHA256
+	10: rsaPrivateKeyFields, // RSASHA512
+	13: {"PrivateKey"},      // ECDSAP256SHA256
+	14: {"PrivateKey"},      // ECDSAP384SHA384
+	15: {"PrivateKey"},      // ED25519
+	16: {"PrivateKey"},      // ED448
+}
+
+// tsigKeyFile is what a .tsig file in the key directory contains, a PowerDNS TSIG key and optionally the zones or
+// secondaries it is limited to.
+type tsigKeyFile struct {
+	powerdns.TSIGKey
+
+	Zones       []string `json:"zones,omitempty"`
+	Secondaries []string `json:"secondaries,omitempty"`
+
+	// unknownFields are any other fields in the file, they are ignored rather than rejected as older versions of the
+	// manager never checked for them.
+	unknownFields []string
+}
+
+// The fields of a TSIG key file, lower case as JSON field names match case insensitively.
+var tsigKeyFileFields = map[string]bool{
+	"name":        true,
+	"id":          true,
+	"algorithm":   true,
+	"key":         true,
+	"type":        true,
+	"zones":       true,
+	"secondaries": true,
+}
+
+// validateTSIGKey parses a TSIG key file and checks it is something PowerDNS will accept. A key without a name is given
+// the name of the file, a key with a different name is rejected as it would never be found again. Unknown fields don't
+// make the key invalid, they are returned for the caller to warn about.
+func validateTSIGKey(keyName string, data []byte) (tsigKey tsigKeyFile, err error) {
+	var fields map[string]json.RawMessage
+	err = json.Unmarshal(data, &fields)
+	if err == nil {
+		err = json.Unmarshal(data, &tsigKey)
+	}
+	if err != nil {
+		err = fmt.Errorf("invalid TSIG key JSON: %w", err)
+		return
+	}
+	for field := range fields {
+		if !tsigKeyFileFields[strings.ToLower(field)] {
+			tsigKey.unknownFields = append(tsigKey.unknownFields, field)
+		}
+	}
+	sort.Strings(tsigKey.unknownFields)
+
+	if tsigKey.Name == nil || *tsigKey.Name == "" {
+		tsigKey.Name = powerdns.String(keyName)
+	} else if strings.TrimSuffix(*tsigKey.Name, ".") != strings.TrimSuffix(keyName, ".") {
+		err = fmt.Errorf("TSIG key name %q does not match the file name %q", *tsigKey.Name, keyName)
+		return
+	}
+
+	if tsigKey.Algorithm == nil || *tsigKey.Algorithm == "" {
+		err = fmt.Errorf("TSIG key has no algorithm")
+		return
+	}
+	algorithm := strings.ToLower(strings.TrimSuffix(*tsigKey.Algorithm, "."))
+	if !tsigAlgorithms[algorithm] {
+		err = fmt.Errorf("unsupported TSIG algorithm %q", *tsigKey.Algorithm)
+		return
+	}
+
+	if tsigKey.Key == nil || *tsigKey.Key == "" {
+		err = fmt.Errorf("TSIG key has no key")
+		return
+	}
+	_, err = base64.StdEncoding.DecodeString(*tsigKey.Key)
+	if err != nil {
+		err = fmt.Errorf("TSIG key is not valid base64: %w", err)
+		return
+	}
+
+	for _, zone := range tsigKey.Zones {
+		if strings.TrimSpace(zone) == "" {
+			err = fmt.Errorf("TSIG key has a blank zone")
+			return
+		}
+	}
+	for _, secondary := range tsigKey.Secondaries {
+		if strings.TrimSpace(secondary) == "" {
+			err = fmt.Errorf("TSIG key has a blank secondary")
+			return
+		}
+	}
+
+	return
+}
+
+// validateDNSSECKey checks a DNSSEC key is in the BIND private key format (what dnssec-keygen and PowerDNS' own export
+// produce) with a supported algorithm and every field that algorithm needs.
+func validateDNSSECKey(data []byte) error {
+	fields := make(map[string]string)
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("line %d is not a \"Field: value\" pair", lineNumber)
+		}
+		fields[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read DNSSEC key: %w", err)
+	}
+
+	format, found := fields["Private-key-format"]
+	if !found {
+		return fmt.Errorf("DNSSEC key has no Private-key-format, it is not a BIND private key")
+	}
+	if !strings.HasPrefix(format, "v1.") {
+		return fmt.Errorf("unsupported Private-key-format %q", format)
+	}
+
+	algorithmField, found := fields["Algorithm"]
+	if !found {
+		return fmt.Errorf("DNSSEC key has no Algorithm")
+	}
+	// The algorithm is given as "13 (ECDSAP256SHA256)".
+	algorithmParts := strings.Fields(algorithmField)
+	if len(algorithmParts) == 0 {
+		return fmt.Errorf("DNSSEC key has a blank Algorithm")
+	}
+	algorithm, err := strconv.Atoi(algorithmParts[0])
+	if err != nil {
+		return fmt.Errorf("DNSSEC key algorithm %q is not a number", algorithmField)
+	}
+
+	requiredFields, supported := dnssecAlgorithmPrivateKeyFields[algorithm]
+	if !supported {
+		return fmt.Errorf("unsupported DNSSEC algorithm %q", algorithmField)
+	}
+
+	for _, requiredField := range requiredFields {
+		value, found := fields[requiredField]
+		if !found || value == "" {
+			return fmt.Errorf("DNSSEC key is missing %s", requiredField)
+		}
+
+		_, err = base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return fmt.Errorf("DNSSEC key %s is not valid base64: %w", requiredField, err)
+		}
+	}
+
+	return nil
+}
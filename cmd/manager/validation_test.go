@@ -0,0 +1,113 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestValidateTSIGKey(t *testing.T) {
+	tests := []struct {
+		name              string
+		data              string
+		wantName          string
+		wantUnknownFields []string
+		wantErr           bool
+	}{
+		{
+			name:     "named by the file",
+			data:     `{"algorithm": "hmac-sha256", "key": "c2VjcmV0"}`,
+			wantName: "transfer",
+		},
+		{
+			name:     "trailing dot in the name",
+			data:     `{"name": "transfer.", "algorithm": "HMAC-SHA256.", "key": "c2VjcmV0"}`,
+			wantName: "transfer.",
+		},
+		{
+			name:              "unknown fields are ignored",
+			data:              `{"Algorithm": "hmac-sha256", "key": "c2VjcmV0", "owner": "ops", "comment": "x"}`,
+			wantName:          "transfer",
+			wantUnknownFields: []string{"comment", "owner"},
+		},
+		{
+			name:    "not JSON",
+			data:    `algorithm: hmac-sha256`,
+			wantErr: true,
+		},
+		{
+			name:    "different name",
+			data:    `{"name": "other", "algorithm": "hmac-sha256", "key": "c2VjcmV0"}`,
+			wantErr: true,
+		},
+		{
+			name:    "unsupported algorithm",
+			data:    `{"algorithm": "hmac-sha3", "key": "c2VjcmV0"}`,
+			wantErr: true,
+		},
+		{
+			name:    "key isn't base64",
+			data:    `{"algorithm": "hmac-sha256", "key": "not base64!"}`,
+			wantErr: true,
+		},
+		{
+			name:    "blank zone",
+			data:    `{"algorithm": "hmac-sha256", "key": "c2VjcmV0", "zones": [" "]}`,
+			wantErr: true,
+		},
+		{
+			name:     "limited to secondaries",
+			data:     `{"algorithm": "hmac-sha256", "key": "c2VjcmV0", "secondaries": ["secondary.example.com"]}`,
+			wantName: "transfer",
+		},
+		{
+			name:    "blank secondary",
+			data:    `{"algorithm": "hmac-sha256", "key": "c2VjcmV0", "secondaries": [""]}`,
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tsigKey, err := validateTSIGKey("transfer", []byte(test.data))
+			if (err != nil) != test.wantErr {
+				t.Fatalf("validateTSIGKey() error = %v, wantErr %v", err, test.wantErr)
+			}
+			if test.wantErr {
+				return
+			}
+
+			if *tsigKey.Name != test.wantName {
+				t.Errorf("validateTSIGKey() name = %s, want %s", *tsigKey.Name, test.wantName)
+			}
+			if !reflect.DeepEqual(tsigKey.unknownFields, test.wantUnknownFields) {
+				t.Errorf("validateTSIGKey() unknown fields = %v, want %v", tsigKey.unknownFields,
+					test.wantUnknownFields)
+			}
+		})
+	}
+}
@@ -0,0 +1,533 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Cray-HPE/cray-powerdns-manager/internal/common"
+	"github.com/joeig/go-powerdns/v2"
+	"go.uber.org/zap"
+)
+
+// Actions that can be taken for a zone the manager created that no longer corresponds to anything in SLS.
+const (
+	OrphanedZoneActionNone       = "none"
+	OrphanedZoneActionQuarantine = "quarantine"
+	OrphanedZoneActionDelete     = "delete"
+)
+
+// getZoneKeys finds the custom DNSSEC key for the zone, if there is one, and the TSIG keys used for zone transfers.
+func getZoneKeys(zoneName string) (customDNSSECKey *common.DNSKey, tsigKeyIDs []string) {
+	for _, key := range getDNSKeys() {
+		if key.Type == common.DNSSecKeyType && strings.TrimSuffix(zoneName, ".") == key.Name {
+			// Required because the loop variable itself is a reference.
+			tmpKey := key
+			customDNSSECKey = &tmpKey
+		}
+		if key.Type == common.TSIGKeyType && isTSIGKeyForZone(key, zoneName) {
+			tsigKeyIDs = append(tsigKeyIDs, key.Name)
+		}
+	}
+
+	return
+}
+
+// isTSIGKeyForZone returns true if the TSIG key should be used for transfers of the zone. A key limited to zones is only
+// used for those, a key limited to secondaries only for the zones transferred to at least one of them, and a key with
+// both for the zones that match both.
+func isTSIGKeyForZone(key common.DNSKey, zoneName string) bool {
+	return isTSIGKeyForZoneName(key, zoneName) && isTSIGKeyForZoneSecondaries(key, zoneName)
+}
+
+// isTSIGKeyForZoneName returns true if the zone is in the zones the TSIG key is limited to, if it is limited at all.
+func isTSIGKeyForZoneName(key common.DNSKey, zoneName string) bool {
+	if len(key.Zones) == 0 {
+		return true
+	}
+
+	for _, keyZone := range key.Zones {
+		if strings.EqualFold(common.MakeDomainCanonical(keyZone), common.MakeDomainCanonical(zoneName)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isTSIGKeyForZoneSecondaries returns true if the zone is transferred to one of the secondaries the TSIG key is limited
+// to, if it is limited at all. The secondaries get every zone that is notified.
+func isTSIGKeyForZoneSecondaries(key common.DNSKey, zoneName string) bool {
+	if len(key.Secondaries) == 0 {
+		return true
+	}
+	if !isNotifyZone(zoneName) {
+		return false
+	}
+
+	for _, keySecondary := range key.Secondaries {
+		for _, slaveNameserver := range slaveNameservers {
+			if strings.EqualFold(common.MakeDomainCanonical(keySecondary), slaveNameserver.FQDN) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// tsigKeyOwnershipMetadataKind is the zone metadata listing the TSIG keys the manager attached to the zone. It outlives
+// the manager so keys removed from the key directory while it wasn't running are still known to be its to remove.
+const tsigKeyOwnershipMetadataKind = "X-CRAY-POWERDNS-MANAGER-TSIG-KEYS"
+
+var (
+	// zoneOwnedTSIGKeys caches the TSIG key ownership metadata of each zone, it only changes when the manager changes it.
+	zoneOwnedTSIGKeys    = make(map[string][]string)
+	zoneOwnedTSIGKeysMtx sync.Mutex
+)
+
+// getZoneOwnedTSIGKeys returns the normalized names of the TSIG keys the manager has attached to the zone.
+func getZoneOwnedTSIGKeys(zoneName string) (keyNames []string, err error) {
+	zoneName = common.MakeDomainCanonical(zoneName)
+
+	zoneOwnedTSIGKeysMtx.Lock()
+	keyNames, found := zoneOwnedTSIGKeys[zoneName]
+	zoneOwnedTSIGKeysMtx.Unlock()
+	if found {
+		return
+	}
+
+	var metadata zoneMetadata
+	err = doPowerDNSRequest(http.MethodGet, fmt.Sprintf("zones/%s/metadata/%s", zoneName,
+		tsigKeyOwnershipMetadataKind), nil, &metadata)
+	if err != nil {
+		pdnsErr, ok := err.(*powerdns.Error)
+		if !ok || pdnsErr.StatusCode != http.StatusNotFound {
+			err = fmt.Errorf("failed to get TSIG key ownership: %w", err)
+			return
+		}
+		err = nil
+	}
+	keyNames = normalizeNames(metadata.Metadata)
+
+	zoneOwnedTSIGKeysMtx.Lock()
+	zoneOwnedTSIGKeys[zoneName] = keyNames
+	zoneOwnedTSIGKeysMtx.Unlock()
+
+	return
+}
+
+// setZoneOwnedTSIGKeys records the TSIG keys the manager has attached to the zone.
+func setZoneOwnedTSIGKeys(zoneName string, keyNames []string) error {
+	zoneName = common.MakeDomainCanonical(zoneName)
+	keyNames = normalizeNames(keyNames)
+
+	err := doPowerDNSRequest(http.MethodPut, fmt.Sprintf("zones/%s/metadata/%s", zoneName,
+		tsigKeyOwnershipMetadataKind), zoneMetadata{Kind: tsigKeyOwnershipMetadataKind, Metadata: keyNames}, nil)
+	if err != nil {
+		return fmt.Errorf("failed to save TSIG key ownership: %w", err)
+	}
+
+	zoneOwnedTSIGKeysMtx.Lock()
+	zoneOwnedTSIGKeys[zoneName] = keyNames
+	zoneOwnedTSIGKeysMtx.Unlock()
+
+	return nil
+}
+
+// isKnownTSIGKey returns true if the TSIG key is in the key directory.
+func isKnownTSIGKey(keyName string) bool {
+	for _, key := range getDNSKeys() {
+		if key.Type == common.TSIGKeyType &&
+			strings.EqualFold(common.MakeDomainCanonical(key.Name), common.MakeDomainCanonical(keyName)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// setZoneMasterTSIGKeyIDs replaces the TSIG keys used for transfers of the zone. The client library can't send an empty
+// list so this goes straight to the API.
+func setZoneMasterTSIGKeyIDs(zoneName string, tsigKeyIDs []string) error {
+	if tsigKeyIDs == nil {
+		tsigKeyIDs = []string{}
+	}
+
+	return doPowerDNSRequest(http.MethodPut, fmt.Sprintf("zones/%s", zoneName),
+		map[string][]string{"master_tsig_key_ids": tsigKeyIDs}, nil)
+}
+
+// getSOAEditAPI returns the SOA-EDIT-API to create zones with, nil leaves it to the PowerDNS default.
+func getSOAEditAPI() *string {
+	if *soaEditAPI == "" {
+		return nil
+	}
+
+	return soaEditAPI
+}
+
+// normalizeNames makes every name canonical and sorts them so two lists can be compared.
+func normalizeNames(names []string) []string {
+	normalized := []string{}
+	for _, name := range names {
+		normalized = append(normalized, strings.ToLower(common.MakeDomainCanonical(name)))
+	}
+	sort.Strings(normalized)
+
+	return normalized
+}
+
+// reconcileZone makes an existing zone match the flags, any changes to the kind, account and TSIG keys are made
+// directly while changes to the NS and SOA RRsets are added to the plan to be patched along with everything else.
+func reconcileZone(zone *powerdns.Zone, nameserverFQDNs []string, soa powerdns.RRset, plan *Plan, dryRun bool) {
+	zoneName := *zone.Name
+	zonePlan := plan.getZonePlan(zoneName)
+	zoneLogger := logger.With(zap.String("zoneName", zoneName))
+
+	// Start with the zone itself.
+	_, tsigKeyIDs := getZoneKeys(zoneName)
+	zoneChange := &powerdns.Zone{}
+	changed := false
+
+	if zone.Kind == nil || *zone.Kind != powerdns.MasterZoneKind {
+		zoneChange.Kind = powerdns.ZoneKindPtr(powerdns.MasterZoneKind)
+		zonePlan.ZoneChanges = append(zonePlan.ZoneChanges, "kind")
+		changed = true
+	}
+	if zone.Account == nil || *zone.Account != common.ManagerAccount {
+		// Either a zone created before the account was used to mark ownership or one that was quarantined and is
+		// needed again.
+		zoneChange.Account = powerdns.String(common.ManagerAccount)
+		zonePlan.ZoneChanges = append(zonePlan.ZoneChanges, "account")
+		changed = true
+	}
+	if *soaEditAPI != "" && (zone.SOAEditAPI == nil || *zone.SOAEditAPI != *soaEditAPI) {
+		zoneChange.SOAEditAPI = soaEditAPI
+		zonePlan.ZoneChanges = append(zonePlan.ZoneChanges, "soa_edit_api")
+		changed = true
+	}
+	// Without the key directory there is no way of knowing which keys the zone should have, and without the ownership
+	// metadata which keys are the manager's to take away, either way leave them alone.
+	tsigKeysChanged := false
+	reconcileTSIGKeys := haveKeysLoaded()
+	if !reconcileTSIGKeys {
+		zoneLogger.Warn("Keys have not been loaded, not updating zone TSIG keys.")
+	}
+	var ownedTSIGKeyIDs []string
+	if reconcileTSIGKeys {
+		var ownershipErr error
+		ownedTSIGKeyIDs, ownershipErr = getZoneOwnedTSIGKeys(zoneName)
+		if ownershipErr != nil {
+			zoneLogger.Error("Failed to get zone TSIG key ownership, not updating zone TSIG keys!",
+				zap.Error(ownershipErr))
+			reconcileTSIGKeys = false
+		}
+	}
+	isOwnedTSIGKey := func(tsigKeyID string) bool {
+		return isLoadedTSIGKey(tsigKeyID) || common.SliceContains(normalizeKeyName(tsigKeyID), ownedTSIGKeyIDs)
+	}
+	ownedTSIGKeysChanged := false
+	if reconcileTSIGKeys {
+		ownedTSIGKeysChanged = strings.Join(ownedTSIGKeyIDs, ",") != strings.Join(normalizeNames(tsigKeyIDs), ",")
+
+		// Keys someone else attached to the zone stay attached.
+		for _, tsigKeyID := range zone.MasterTSIGKeyIDs {
+			if !isOwnedTSIGKey(tsigKeyID) {
+				tsigKeyIDs = append(tsigKeyIDs, tsigKeyID)
+			}
+		}
+
+		tsigKeysChanged = strings.Join(normalizeNames(zone.MasterTSIGKeyIDs), ",") !=
+			strings.Join(normalizeNames(tsigKeyIDs), ",")
+	}
+	if tsigKeysChanged {
+		zonePlan.ZoneChanges = append(zonePlan.ZoneChanges, "master_tsig_key_ids")
+	}
+
+	if changed || tsigKeysChanged {
+		zonePlan.ReplaceZone = true
+		if dryRun {
+			zoneLogger.Info("Zone would be updated", zap.Strings("changes", zonePlan.ZoneChanges))
+		} else {
+			if changed {
+				err := pdns.Zones.Change(zoneName, zoneChange)
+				recordPowerDNSResult(err)
+				if err != nil {
+					zoneLogger.Error("Failed to update zone!", zap.Error(err), zap.Any("zoneChange", zoneChange))
+					zonePlan.Error = fmt.Sprintf("failed to update zone: %s", err)
+					return
+				}
+			}
+			if tsigKeysChanged {
+				err := setZoneMasterTSIGKeyIDs(zoneName, tsigKeyIDs)
+				if err != nil {
+					zoneLogger.Error("Failed to update zone TSIG keys!", zap.Error(err),
+						zap.Strings("tsigKeyIDs", tsigKeyIDs))
+					zonePlan.Error = fmt.Sprintf("failed to update zone TSIG keys: %s", err)
+					return
+				}
+
+				// Keys that have gone from the key directory shouldn't be left behind in PowerDNS either. Only keys
+				// the manager attached itself are removed, loadKeys normally gets there first but this catches any
+				// delete that failed or any key removed while the manager wasn't running.
+				for _, tsigKeyID := range zone.MasterTSIGKeyIDs {
+					if isKnownTSIGKey(tsigKeyID) || !isOwnedTSIGKey(tsigKeyID) {
+						continue
+					}
+
+					err = DeleteTSIGKey(tsigKeyID)
+					if err != nil {
+						zoneLogger.Error("Failed to delete stale TSIG key!", zap.Error(err),
+							zap.String("tsigKeyID", tsigKeyID))
+					} else {
+						zoneLogger.Info("Deleted stale TSIG key", zap.String("tsigKeyID", tsigKeyID))
+					}
+				}
+				zone.MasterTSIGKeyIDs = tsigKeyIDs
+			}
+			zoneLogger.Info("Updated zone", zap.Strings("changes", zonePlan.ZoneChanges))
+		}
+	}
+
+	// Only once the keys are attached are they recorded as the manager's, the record is never ahead of the zone.
+	if ownedTSIGKeysChanged && !dryRun && zonePlan.Error == "" {
+		_, desiredTSIGKeyIDs := getZoneKeys(zoneName)
+		err := setZoneOwnedTSIGKeys(zoneName, desiredTSIGKeyIDs)
+		if err != nil {
+			zoneLogger.Error("Failed to record zone TSIG key ownership!", zap.Error(err))
+		}
+	}
+
+	// Now the NS and SOA at the apex.
+	desiredNS := powerdns.RRset{
+		Name:       powerdns.String(zoneName),
+		Type:       powerdns.RRTypePtr(powerdns.RRTypeNS),
+		TTL:        powerdns.Uint32(recordTTLs.Get(powerdns.RRTypeNS, "")),
+		ChangeType: powerdns.ChangeTypePtr(powerdns.ChangeTypeReplace),
+	}
+	for _, nameserverFQDN := range normalizeNames(nameserverFQDNs) {
+		desiredNS.Records = append(desiredNS.Records, powerdns.Record{
+			Content:  powerdns.String(nameserverFQDN),
+			Disabled: powerdns.Bool(false),
+		})
+	}
+
+	var existingNameserverFQDNs []string
+	var existingSOA *powerdns.RRset
+	for i, rrSet := range zone.RRsets {
+		if *rrSet.Name != zoneName {
+			continue
+		}
+
+		switch *rrSet.Type {
+		case powerdns.RRTypeNS:
+			for _, record := range rrSet.Records {
+				existingNameserverFQDNs = append(existingNameserverFQDNs, *record.Content)
+			}
+		case powerdns.RRTypeSOA:
+			existingSOA = &zone.RRsets[i]
+		}
+	}
+
+	if strings.Join(normalizeNames(existingNameserverFQDNs), ",") !=
+		strings.Join(normalizeNames(nameserverFQDNs), ",") {
+		zonePlan.Replaces = append(zonePlan.Replaces, desiredNS)
+		zonePlan.ZoneChanges = append(zonePlan.ZoneChanges, "nameservers")
+		zonePlan.ReplaceZone = true
+		zoneLogger.Info("Zone nameservers have changed, adding to patch list.",
+			zap.Strings("existingNameserverFQDNs", existingNameserverFQDNs),
+			zap.Strings("nameserverFQDNs", nameserverFQDNs))
+	}
+
+	if existingSOA != nil && len(existingSOA.Records) > 0 {
+		desiredSOA := soa
+		desiredSOA.Name = powerdns.String(zoneName)
+
+		if !common.RRsetsEqual(desiredSOA, *existingSOA) {
+			// Keep the existing serial, it only ever goes forward and PowerDNS will bump it when the change is patched.
+			existingSerial, serialErr := common.GetSOASerial(*existingSOA.Records[0].Content)
+			if serialErr == nil {
+				content, _ := common.SetSOASerial(*soa.Records[0].Content, existingSerial)
+				desiredSOA.Records = []powerdns.Record{
+					{
+						Content:  powerdns.String(content),
+						Disabled: powerdns.Bool(false),
+					},
+				}
+			}
+
+			zonePlan.Replaces = append(zonePlan.Replaces, desiredSOA)
+			zonePlan.ZoneChanges = append(zonePlan.ZoneChanges, "soa")
+			zonePlan.ReplaceZone = true
+			zoneLogger.Info("Zone SOA has changed, adding to patch list.",
+				zap.Any("existingSOA", existingSOA), zap.Any("desiredSOA", desiredSOA))
+		}
+	}
+}
+
+// getSOA returns the SOA from a list of RRsets.
+func getSOA(rrSets []powerdns.RRset) (soa powerdns.RRset, found bool) {
+	for _, rrSet := range rrSets {
+		if *rrSet.Type == powerdns.RRTypeSOA {
+			return rrSet, true
+		}
+	}
+
+	return
+}
+
+// isManagerOwnedZone returns true if the manager created the zone, including zones it has since quarantined.
+func isManagerOwnedZone(zone powerdns.Zone) bool {
+	return zone.Account != nil &&
+		(*zone.Account == common.ManagerAccount || *zone.Account == common.ManagerQuarantineAccount)
+}
+
+// lastDesiredZones are the zones the last run that wasn't a dry run wanted, nil until there has been one. Like the state
+// cache it is only touched by true up runs, which never overlap.
+var lastDesiredZones map[string]bool
+
+// getOrphanedZoneDeleteRefusal returns why orphaned zones shouldn't be deleted this run, if there is a reason. Deleting
+// a zone can't be undone so anything that looks like SLS returning too little stops it: no networks at all, no earlier
+// run to compare against, or more zones going at once than the limit.
+func getOrphanedZoneDeleteRefusal(networkCount int, plan *Plan, orphanedZoneCount int) string {
+	if networkCount == 0 {
+		return "SLS returned no networks"
+	}
+	if lastDesiredZones == nil {
+		return "there is no earlier run to compare the desired zones against"
+	}
+
+	undesiredZoneCount := 0
+	for zoneName := range lastDesiredZones {
+		if !plan.isDesiredZone(zoneName) {
+			undesiredZoneCount++
+		}
+	}
+	if undesiredZoneCount > *orphanedZoneDeleteLimit {
+		return fmt.Sprintf("%d zones stopped being desired since the last run, more than the limit of %d",
+			undesiredZoneCount, *orphanedZoneDeleteLimit)
+	}
+	if orphanedZoneCount > *orphanedZoneDeleteLimit {
+		return fmt.Sprintf("%d zones are orphaned, more than the limit of %d", orphanedZoneCount,
+			*orphanedZoneDeleteLimit)
+	}
+
+	return ""
+}
+
+// trueUpOrphanedZones finds the zones the manager created that are no longer desired, for example because the SLS
+// network they were created for was removed, and quarantines or deletes them.
+func trueUpOrphanedZones(networkCount int, plan *Plan, dryRun bool) (err error) {
+	if *orphanedZoneAction == OrphanedZoneActionNone {
+		return
+	}
+
+	zones, err := pdns.Zones.List()
+	recordPowerDNSResult(err)
+	if err != nil {
+		err = fmt.Errorf("failed to list zones: %w", err)
+		return
+	}
+
+	var orphanedZones []powerdns.Zone
+	for _, zone := range zones {
+		if zone.Name == nil || !isManagerOwnedZone(zone) || plan.isDesiredZone(*zone.Name) {
+			continue
+		}
+		orphanedZones = append(orphanedZones, zone)
+	}
+
+	var deleteRefusal string
+	if *orphanedZoneAction == OrphanedZoneActionDelete {
+		deleteRefusal = getOrphanedZoneDeleteRefusal(networkCount, plan, len(orphanedZones))
+		if deleteRefusal != "" && len(orphanedZones) > 0 {
+			logger.Error("Not deleting any orphaned zones this run!", zap.String("reason", deleteRefusal),
+				zap.Int("orphanedZones", len(orphanedZones)))
+		}
+	}
+	if !dryRun {
+		lastDesiredZones = make(map[string]bool)
+		for zoneName := range plan.desiredZones {
+			lastDesiredZones[zoneName] = true
+		}
+	}
+
+	for _, zone := range orphanedZones {
+		zoneName := common.MakeDomainCanonical(*zone.Name)
+		zoneLogger := logger.With(zap.String("zoneName", zoneName))
+
+		switch *orphanedZoneAction {
+		case OrphanedZoneActionQuarantine:
+			if *zone.Account == common.ManagerQuarantineAccount {
+				continue
+			}
+
+			zonePlan := plan.getZonePlan(zoneName)
+			zonePlan.QuarantineZone = true
+			if dryRun {
+				zoneLogger.Info("Orphaned zone would be quarantined")
+				continue
+			}
+
+			changeErr := pdns.Zones.Change(zoneName, &powerdns.Zone{
+				Account: powerdns.String(common.ManagerQuarantineAccount),
+			})
+			if changeErr != nil {
+				zoneLogger.Error("Failed to quarantine orphaned zone!", zap.Error(changeErr))
+				zonePlan.Error = fmt.Sprintf("failed to quarantine zone: %s", changeErr)
+				continue
+			}
+			zoneLogger.Warn("Quarantined orphaned zone")
+		case OrphanedZoneActionDelete:
+			if deleteRefusal != "" {
+				zoneLogger.Warn("Orphaned zone not deleted", zap.String("reason", deleteRefusal))
+				continue
+			}
+
+			zonePlan := plan.getZonePlan(zoneName)
+			zonePlan.DeleteZone = true
+			if dryRun {
+				zoneLogger.Info("Orphaned zone would be deleted")
+				continue
+			}
+
+			deleteErr := pdns.Zones.Delete(zoneName)
+			if deleteErr != nil {
+				zoneLogger.Error("Failed to delete orphaned zone!", zap.Error(deleteErr))
+				zonePlan.Error = fmt.Sprintf("failed to delete zone: %s", deleteErr)
+				continue
+			}
+			zoneLogger.Warn("Deleted orphaned zone")
+		}
+	}
+
+	return
+}
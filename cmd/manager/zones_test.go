@@ -0,0 +1,119 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package main
+
+import (
+	"testing"
+
+	"github.com/Cray-HPE/cray-powerdns-manager/internal/common"
+)
+
+func TestGetOrphanedZoneDeleteRefusal(t *testing.T) {
+	desiredZones := []string{"example.com.", "nmn.example.com.", "hmn.example.com.", "252.10.in-addr.arpa."}
+
+	tests := []struct {
+		name              string
+		networkCount      int
+		lastDesiredZones  []string
+		desiredZones      []string
+		orphanedZoneCount int
+		wantRefusal       bool
+	}{
+		{name: "nothing changed", networkCount: 2, lastDesiredZones: desiredZones, desiredZones: desiredZones},
+		{name: "one network removed", networkCount: 1, lastDesiredZones: desiredZones,
+			desiredZones: desiredZones[:3], orphanedZoneCount: 1},
+		{name: "no networks", networkCount: 0, lastDesiredZones: desiredZones, desiredZones: desiredZones[:1],
+			orphanedZoneCount: 1, wantRefusal: true},
+		{name: "no earlier run", networkCount: 2, desiredZones: desiredZones, orphanedZoneCount: 1,
+			wantRefusal: true},
+		{name: "too many zones stopped being desired", networkCount: 1, lastDesiredZones: desiredZones,
+			desiredZones: desiredZones[:1], wantRefusal: true},
+		{name: "too many orphaned zones", networkCount: 2, lastDesiredZones: desiredZones, desiredZones: desiredZones,
+			orphanedZoneCount: 3, wantRefusal: true},
+	}
+
+	defer func() { lastDesiredZones = nil }()
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			lastDesiredZones = nil
+			if test.lastDesiredZones != nil {
+				lastDesiredZones = make(map[string]bool)
+				for _, zoneName := range test.lastDesiredZones {
+					lastDesiredZones[zoneName] = true
+				}
+			}
+			plan := NewPlan(false)
+			for _, zoneName := range test.desiredZones {
+				plan.addDesiredZone(zoneName)
+			}
+
+			refusal := getOrphanedZoneDeleteRefusal(test.networkCount, plan, test.orphanedZoneCount)
+			if (refusal != "") != test.wantRefusal {
+				t.Errorf("getOrphanedZoneDeleteRefusal() = %q, want refusal %t", refusal, test.wantRefusal)
+			}
+		})
+	}
+}
+
+func TestIsTSIGKeyForZone(t *testing.T) {
+	defer func(nameservers []common.Nameserver, zones []string) {
+		slaveNameservers, notifyZonesArray = nameservers, zones
+	}(slaveNameservers, notifyZonesArray)
+	slaveNameservers = []common.Nameserver{{FQDN: "secondary.example.com."}}
+	notifyZonesArray = []string{"example.com", "nmn.example.com"}
+
+	tests := []struct {
+		name     string
+		key      common.DNSKey
+		zoneName string
+		want     bool
+	}{
+		{name: "not limited", zoneName: "hmn.example.com.", want: true},
+		{name: "in its zones", key: common.DNSKey{Zones: []string{"NMN.example.com"}}, zoneName: "nmn.example.com.",
+			want: true},
+		{name: "not in its zones", key: common.DNSKey{Zones: []string{"nmn.example.com"}},
+			zoneName: "hmn.example.com."},
+		{name: "transferred to its secondary", key: common.DNSKey{Secondaries: []string{"Secondary.example.com"}},
+			zoneName: "nmn.example.com.", want: true},
+		{name: "not transferred", key: common.DNSKey{Secondaries: []string{"secondary.example.com"}},
+			zoneName: "hmn.example.com."},
+		{name: "unknown secondary", key: common.DNSKey{Secondaries: []string{"other.example.com"}},
+			zoneName: "nmn.example.com."},
+		{name: "in its zones and transferred to its secondary", key: common.DNSKey{Zones: []string{"example.com"},
+			Secondaries: []string{"secondary.example.com."}}, zoneName: "example.com.", want: true},
+		{name: "transferred to its secondary but not in its zones", key: common.DNSKey{Zones: []string{"example.com"},
+			Secondaries: []string{"secondary.example.com."}}, zoneName: "nmn.example.com."},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := isTSIGKeyForZone(test.key, test.zoneName); got != test.want {
+				t.Errorf("isTSIGKeyForZone() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"crypto/tls"
 	"fmt"
+	"github.com/Cray-HPE/cray-powerdns-manager/internal/common"
 	"github.com/hashicorp/go-retryablehttp"
 	"github.com/joeig/go-powerdns/v2"
 	"github.com/namsral/flag"
@@ -14,6 +15,7 @@ import (
 )
 
 const rdnsDomain = ".in-addr.arpa"
+const rdns6Domain = ".ip6.arpa"
 
 var (
 	pdnsURL = flag.String("pdns_url", "http://localhost:9090", "PowerDNS URL")
@@ -80,7 +82,13 @@ func main() {
 				thisZoneRecords = append(thisZoneRecords, *rrSet.Name)
 				nodeBranch := zoneBranch.AddMetaBranch(*rrSet.Type, *rrSet.Name)
 
-				if *rrSet.Type == powerdns.RRTypeA || *rrSet.Type == powerdns.RRTypePTR {
+				if ownership, owned := common.GetRRsetOwnership(rrSet); owned {
+					nodeBranch.AddMetaNode("owner", fmt.Sprintf("%s (last seen %s)", ownership,
+						ownership.LastSeen.Format(time.RFC3339)))
+				}
+
+				if *rrSet.Type == powerdns.RRTypeA || *rrSet.Type == powerdns.RRTypeAAAA ||
+					*rrSet.Type == powerdns.RRTypePTR {
 					cnames := getCNAMEsForRRset(*rrSet.Name, zone.RRsets)
 					for _, cname := range cnames {
 						nodeBranch.AddMetaNode(powerdns.RRTypeCNAME, cname)
@@ -95,7 +103,7 @@ func main() {
 			}
 		}
 
-		if !strings.Contains(*zone.Name, rdnsDomain) {
+		if !strings.Contains(*zone.Name, rdnsDomain) && !strings.Contains(*zone.Name, rdns6Domain) {
 			authoratativeRecords[*zone.Name] = thisZoneRecords
 		}
 	}
@@ -0,0 +1,165 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package common
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/joeig/go-powerdns/v2"
+)
+
+// ManagerAccount is the account set on the comment of every RRset the manager generates. PowerDNS keeps comments
+// alongside the RRset so this is how we later tell our records apart from those created by externaldns or by hand.
+const ManagerAccount = "cray-powerdns-manager"
+
+// ManagerQuarantineAccount is the account set on a zone the manager created that no longer corresponds to anything in
+// SLS when the orphaned zone action is to quarantine it.
+const ManagerQuarantineAccount = "cray-powerdns-manager-quarantined"
+
+// Sources of data an RRset can be generated from.
+const (
+	OwnerSourceSLS    = "sls"
+	OwnerSourceHSM    = "hsm"
+	OwnerSourceManual = "manual"
+	OwnerSourceStatic = "static"
+)
+
+// RRsetOwnership describes where a manager owned RRset came from and when the manager last saw that source.
+//
+// It is stored as a PowerDNS comment on the RRset. The content is "<source>:<id>" where the ID is the SLS reservation
+// (network/subnet/reservation), the SLS hardware (hardware/xname), the SLS network (network/name), the HSM
+// EthernetInterface ID, or the operator defined record (records/name/type) from the API or the static records file, and
+// the comment modified_at is the last seen time.
+type RRsetOwnership struct {
+	Source   string    `json:"source"`
+	ID       string    `json:"id"`
+	LastSeen time.Time `json:"last_seen"`
+}
+
+func (ownership RRsetOwnership) String() string {
+	return fmt.Sprintf("%s:%s", ownership.Source, ownership.ID)
+}
+
+// GetSLSReservationOwnership returns the ownership for an RRset generated from an SLS IP reservation.
+func GetSLSReservationOwnership(networkName string, subnetName string, reservationName string) RRsetOwnership {
+	return RRsetOwnership{
+		Source:   OwnerSourceSLS,
+		ID:       fmt.Sprintf("%s/%s/%s", networkName, subnetName, reservationName),
+		LastSeen: time.Now(),
+	}
+}
+
+// GetSLSHardwareOwnership returns the ownership for an RRset generated from an SLS hardware object.
+func GetSLSHardwareOwnership(xname string) RRsetOwnership {
+	return RRsetOwnership{
+		Source:   OwnerSourceSLS,
+		ID:       fmt.Sprintf("hardware/%s", xname),
+		LastSeen: time.Now(),
+	}
+}
+
+// GetSLSNetworkOwnership returns the ownership for an RRset generated from an SLS network as a whole.
+func GetSLSNetworkOwnership(networkName string) RRsetOwnership {
+	return RRsetOwnership{
+		Source:   OwnerSourceSLS,
+		ID:       fmt.Sprintf("network/%s", networkName),
+		LastSeen: time.Now(),
+	}
+}
+
+// GetHSMEthernetInterfaceOwnership returns the ownership for an RRset generated from an HSM EthernetInterface.
+func GetHSMEthernetInterfaceOwnership(ethernetInterfaceID string) RRsetOwnership {
+	return RRsetOwnership{
+		Source:   OwnerSourceHSM,
+		ID:       ethernetInterfaceID,
+		LastSeen: time.Now(),
+	}
+}
+
+// GetManualRecordOwnership returns the ownership for an RRset defined by an operator through the manager API.
+func GetManualRecordOwnership(name string, rrType string) RRsetOwnership {
+	return RRsetOwnership{
+		Source:   OwnerSourceManual,
+		ID:       fmt.Sprintf("records/%s/%s", name, rrType),
+		LastSeen: time.Now(),
+	}
+}
+
+// GetStaticRecordOwnership returns the ownership for an RRset defined in the static records file.
+func GetStaticRecordOwnership(name string, rrType string) RRsetOwnership {
+	return RRsetOwnership{
+		Source:   OwnerSourceStatic,
+		ID:       fmt.Sprintf("records/%s/%s", name, rrType),
+		LastSeen: time.Now(),
+	}
+}
+
+// GetOwnershipComments returns the comments that mark an RRset as owned by the manager.
+func GetOwnershipComments(ownership RRsetOwnership) []powerdns.Comment {
+	return []powerdns.Comment{
+		{
+			Content:    powerdns.String(ownership.String()),
+			Account:    powerdns.String(ManagerAccount),
+			ModifiedAt: powerdns.Uint64(uint64(ownership.LastSeen.Unix())),
+		},
+	}
+}
+
+// GetRRsetOwnership parses the manager ownership comment of an RRset. If the RRset is not owned by the manager then
+// owned is false. RRsets marked before the source was recorded are owned but have a blank source.
+func GetRRsetOwnership(rrSet powerdns.RRset) (ownership RRsetOwnership, owned bool) {
+	for _, comment := range rrSet.Comments {
+		if comment.Account == nil || *comment.Account != ManagerAccount {
+			continue
+		}
+
+		owned = true
+
+		if comment.Content != nil {
+			contentParts := strings.SplitN(*comment.Content, ":", 2)
+			if len(contentParts) == 2 {
+				ownership.Source = contentParts[0]
+				ownership.ID = contentParts[1]
+			}
+		}
+		if comment.ModifiedAt != nil {
+			ownership.LastSeen = time.Unix(int64(*comment.ModifiedAt), 0)
+		}
+
+		return
+	}
+
+	return
+}
+
+// IsManagerOwned returns true if the RRset carries the manager ownership comment.
+func IsManagerOwned(rrSet powerdns.RRset) bool {
+	_, owned := GetRRsetOwnership(rrSet)
+
+	return owned
+}
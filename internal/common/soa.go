@@ -0,0 +1,107 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package common
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joeig/go-powerdns/v2"
+)
+
+// The index of the serial in the content of a SOA record, "mname rname serial refresh retry expire minimum".
+const soaSerialField = 2
+
+// GetDateSOASerial returns the first date based serial (YYYYMMDDnn) for the given day.
+func GetDateSOASerial(now time.Time) uint32 {
+	return uint32(now.Year()*1000000 + int(now.Month())*10000 + now.Day()*100)
+}
+
+// GetSOASerial returns the serial from the content of a SOA record.
+func GetSOASerial(content string) (serial uint32, err error) {
+	fields := strings.Fields(content)
+	if len(fields) != 7 {
+		err = fmt.Errorf("SOA content does not have 7 fields: %s", content)
+		return
+	}
+
+	parsed, err := strconv.ParseUint(fields[soaSerialField], 10, 32)
+	if err != nil {
+		err = fmt.Errorf("failed to parse SOA serial: %w", err)
+		return
+	}
+
+	serial = uint32(parsed)
+	return
+}
+
+// SetSOASerial returns the content of a SOA record with the serial replaced.
+func SetSOASerial(content string, serial uint32) (string, error) {
+	fields := strings.Fields(content)
+	if len(fields) != 7 {
+		return "", fmt.Errorf("SOA content does not have 7 fields: %s", content)
+	}
+
+	fields[soaSerialField] = strconv.FormatUint(uint64(serial), 10)
+
+	return strings.Join(fields, " "), nil
+}
+
+// soaContentWithoutSerial blanks the serial so two SOA records can be compared on everything the manager controls.
+func soaContentWithoutSerial(content string) string {
+	fields := strings.Fields(content)
+	if len(fields) == 7 {
+		fields[soaSerialField] = ""
+	}
+
+	return strings.Join(fields, " ")
+}
+
+// soaRecordsEqual compares two sets of SOA records ignoring the serial as PowerDNS rewrites that on every change.
+func soaRecordsEqual(a []powerdns.Record, b []powerdns.Record) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i].Content == nil || b[i].Content == nil {
+			if a[i].Content != b[i].Content {
+				return false
+			}
+		} else if soaContentWithoutSerial(*a[i].Content) != soaContentWithoutSerial(*b[i].Content) {
+			return false
+		}
+
+		if (a[i].Disabled == nil) != (b[i].Disabled == nil) ||
+			(a[i].Disabled != nil && *a[i].Disabled != *b[i].Disabled) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,95 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package common
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/joeig/go-powerdns/v2"
+)
+
+// DefaultTTL is the TTL of any RRset without a more specific one.
+const DefaultTTL uint32 = 3600
+
+// TTLs are the TTLs to give the RRsets the managers generate. An RRset built for an SLS network gets the TTL for that
+// network if there is one, otherwise every RRset gets the TTL for its type and failing that the default.
+type TTLs struct {
+	Types    map[powerdns.RRType]uint32
+	Networks map[string]uint32
+}
+
+// Get returns the TTL for an RRset of the given type, the network name is empty for RRsets that don't belong to one.
+func (ttls TTLs) Get(rrType powerdns.RRType, networkName string) uint32 {
+	if ttl, found := ttls.Networks[strings.ToLower(networkName)]; found && networkName != "" {
+		return ttl
+	}
+	if ttl, found := ttls.Types[rrType]; found {
+		return ttl
+	}
+
+	return DefaultTTL
+}
+
+// ValidateTTL checks a TTL given as an unsigned integer flag fits in an RRset.
+func ValidateTTL(ttl uint) (uint32, error) {
+	if ttl > math.MaxInt32 {
+		return 0, fmt.Errorf("TTL %d is more than the maximum of %d", ttl, math.MaxInt32)
+	}
+
+	return uint32(ttl), nil
+}
+
+// ParseNetworkTTLs parses a comma separated list of network=ttl pairs, e.g. "hmn=300,nmn=86400". The network names are
+// case insensitive.
+func ParseNetworkTTLs(networkTTLs string) (map[string]uint32, error) {
+	ttls := make(map[string]uint32)
+	if networkTTLs == "" {
+		return ttls, nil
+	}
+
+	for _, networkTTL := range strings.Split(networkTTLs, ",") {
+		parts := strings.Split(networkTTL, "=")
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, fmt.Errorf("%s is not network=ttl", networkTTL)
+		}
+
+		ttl, err := strconv.ParseUint(strings.TrimSpace(parts[1]), 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid TTL for network %s: %w", parts[0], err)
+		}
+		validTTL, err := ValidateTTL(uint(ttl))
+		if err != nil {
+			return nil, fmt.Errorf("invalid TTL for network %s: %w", parts[0], err)
+		}
+
+		ttls[strings.ToLower(strings.TrimSpace(parts[0]))] = validTTL
+	}
+
+	return ttls, nil
+}
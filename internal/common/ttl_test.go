@@ -0,0 +1,98 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package common
+
+import (
+	"testing"
+
+	"github.com/joeig/go-powerdns/v2"
+)
+
+func TestParseNetworkTTLs(t *testing.T) {
+	tests := []struct {
+		name        string
+		networkTTLs string
+		want        map[string]uint32
+		wantErr     bool
+	}{
+		{name: "empty", networkTTLs: "", want: map[string]uint32{}},
+		{name: "one", networkTTLs: "hmn=300", want: map[string]uint32{"hmn": 300}},
+		{name: "several", networkTTLs: "HMN=300, nmn = 86400", want: map[string]uint32{"hmn": 300, "nmn": 86400}},
+		{name: "no TTL", networkTTLs: "hmn", wantErr: true},
+		{name: "no network", networkTTLs: "=300", wantErr: true},
+		{name: "not a number", networkTTLs: "hmn=soon", wantErr: true},
+		{name: "negative", networkTTLs: "hmn=-1", wantErr: true},
+		{name: "too big", networkTTLs: "hmn=4294967295", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ParseNetworkTTLs(test.networkTTLs)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("ParseNetworkTTLs(%q) error = %v, wantErr %v", test.networkTTLs, err, test.wantErr)
+			}
+			if test.wantErr {
+				return
+			}
+			if len(got) != len(test.want) {
+				t.Fatalf("ParseNetworkTTLs(%q) = %v, want %v", test.networkTTLs, got, test.want)
+			}
+			for network, ttl := range test.want {
+				if got[network] != ttl {
+					t.Errorf("ParseNetworkTTLs(%q)[%s] = %d, want %d", test.networkTTLs, network, got[network], ttl)
+				}
+			}
+		})
+	}
+}
+
+func TestTTLsGet(t *testing.T) {
+	ttls := TTLs{
+		Types:    map[powerdns.RRType]uint32{powerdns.RRTypeA: 600, powerdns.RRTypePTR: 1200},
+		Networks: map[string]uint32{"hmn": 300},
+	}
+
+	tests := []struct {
+		name        string
+		rrType      powerdns.RRType
+		networkName string
+		want        uint32
+	}{
+		{name: "type", rrType: powerdns.RRTypeA, want: 600},
+		{name: "network wins over type", rrType: powerdns.RRTypeA, networkName: "hmn", want: 300},
+		{name: "network is case insensitive", rrType: powerdns.RRTypePTR, networkName: "HMN", want: 300},
+		{name: "network without a TTL", rrType: powerdns.RRTypePTR, networkName: "nmn", want: 1200},
+		{name: "default", rrType: powerdns.RRTypeCNAME, want: DefaultTTL},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := ttls.Get(test.rrType, test.networkName); got != test.want {
+				t.Errorf("Get(%s, %q) = %d, want %d", test.rrType, test.networkName, got, test.want)
+			}
+		})
+	}
+}
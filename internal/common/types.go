@@ -7,6 +7,7 @@ import (
 )
 
 const rdnsDomain = ".in-addr.arpa"
+const rdns6Domain = ".ip6.arpa"
 
 type Nameserver struct {
 	FQDN string
@@ -20,6 +21,12 @@ type NetworkNameCIDRMap struct {
 
 type PowerDNSZones []*powerdns.Zone
 
+// RRsetKey identifies an RRset. A name can have several RRsets as long as they are of different types.
+type RRsetKey struct {
+	Name string
+	Type powerdns.RRType
+}
+
 type DNSKeyType int
 
 const (
@@ -31,6 +38,11 @@ type DNSKey struct {
 	Name string
 	Data string
 	Type DNSKeyType
+
+	// Zones are the zones a TSIG key is used for, every zone if there aren't any.
+	Zones []string
+	// Secondaries limit a TSIG key to the zones transferred to these secondary servers, given by name.
+	Secondaries []string
 }
 
 func (key DNSKey) String() string {
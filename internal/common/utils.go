@@ -34,6 +34,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 )
 
 func MakeDomainCanonical(domain string) string {
@@ -44,11 +45,19 @@ func MakeDomainCanonical(domain string) string {
 	}
 }
 
-// GetReverseZoneName computes a reverse zone name from a slice of IPv4 parts.
+// GetReverseZoneName computes a reverse zone name from a slice of IPv4 parts. IPv6 zones are in ip6.arpa and split on
+// the nibble boundary at or above the prefix length.
 func GetReverseZoneName(cidr *net.IPNet) string {
 	var reverseCIDR []string
 	var cidrParts []string
 
+	if cidr.IP.To4() == nil {
+		prefix, _ := cidr.Mask.Size()
+		nibbles := getIPv6Nibbles(cidr.IP)[0 : prefix/4]
+
+		return fmt.Sprintf("%s%s", strings.Join(reverseStrings(nibbles), "."), rdns6Domain)
+	}
+
 	prefix, _ := strconv.Atoi(strings.Split(cidr.String(), "/")[1])
 
 	if prefix >= 24 {
@@ -66,11 +75,50 @@ func GetReverseZoneName(cidr *net.IPNet) string {
 	return fmt.Sprintf("%s%s", strings.Join(reverseCIDR, "."), rdnsDomain)
 }
 
+// IsClasslessCIDR returns true for an IPv4 network smaller than a /24, which can only have its own reverse zone by way of
+// RFC 2317 classless delegation.
+func IsClasslessCIDR(cidr *net.IPNet) bool {
+	prefix, bits := cidr.Mask.Size()
+
+	return bits == 32 && prefix > 24
+}
+
+// GetClasslessReverseZoneName computes the RFC 2317 reverse zone name for an IPv4 network smaller than a /24. The zone
+// is named <first address>-<prefix> under the /24 reverse zone, e.g., 128-26.5.101.10.in-addr.arpa. A dash is used
+// rather than a slash as the name ends up in PowerDNS API URLs.
+func GetClasslessReverseZoneName(cidr *net.IPNet) string {
+	prefix, _ := cidr.Mask.Size()
+	ip := cidr.IP.To4()
+
+	return fmt.Sprintf("%d-%d.%d.%d.%d%s", ip[3], prefix, ip[2], ip[1], ip[0], rdnsDomain)
+}
+
+// GetClasslessReverseName computes the name of the PTR for an IPv4 address inside an RFC 2317 classless reverse zone.
+func GetClasslessReverseName(ip net.IP, cidr *net.IPNet) string {
+	return fmt.Sprintf("%d.%s", ip.To4()[3], GetClasslessReverseZoneName(cidr))
+}
+
 // GetForwardIP get the IP address from a reverse record name.
 func GetForwardIP(reverseName string) string {
 	var reverseParts []string
 	var forwardIP []string
 
+	if IsIPv6ReverseName(reverseName) {
+		nibbles := reverseStrings(strings.Split(strings.TrimSuffix(MakeDomainCanonical(reverseName),
+			rdns6Domain+"."), "."))
+
+		var groups []string
+		for i := 0; i+4 <= len(nibbles); i += 4 {
+			groups = append(groups, strings.Join(nibbles[i:i+4], ""))
+		}
+
+		ip := net.ParseIP(strings.Join(groups, ":"))
+		if ip == nil {
+			return ""
+		}
+		return ip.String()
+	}
+
 	reverseParts = strings.Split(strings.TrimSuffix(reverseName, ".in-addr.arpa."), ".")
 
 	for i := len(reverseParts) - 1; i >= 0; i-- {
@@ -92,6 +140,47 @@ func GetReverseName(cidrParts []string) string {
 	return fmt.Sprintf("%s%s", strings.Join(reverseCIDR, "."), rdnsDomain)
 }
 
+// GetReverseNameForIP computes the reverse name for either an IPv4 or IPv6 address.
+func GetReverseNameForIP(ip net.IP) string {
+	if ip.To4() != nil {
+		return GetReverseName(strings.Split(ip.To4().String(), "."))
+	}
+
+	return fmt.Sprintf("%s%s", strings.Join(reverseStrings(getIPv6Nibbles(ip)), "."), rdns6Domain)
+}
+
+// IsIPv6ReverseName returns true if the name (of either a zone or a record) is in ip6.arpa.
+func IsIPv6ReverseName(name string) bool {
+	return strings.HasSuffix(MakeDomainCanonical(name), rdns6Domain+".")
+}
+
+// GetAddressRRType returns AAAA for an IPv6 address and A for everything else.
+func GetAddressRRType(ip net.IP) powerdns.RRType {
+	if ip != nil && ip.To4() == nil {
+		return powerdns.RRTypeAAAA
+	}
+
+	return powerdns.RRTypeA
+}
+
+// getIPv6Nibbles returns the 32 hex nibbles of an IPv6 address, most significant first.
+func getIPv6Nibbles(ip net.IP) []string {
+	var nibbles []string
+	for _, b := range ip.To16() {
+		nibbles = append(nibbles, fmt.Sprintf("%x", b>>4), fmt.Sprintf("%x", b&0xf))
+	}
+
+	return nibbles
+}
+
+func reverseStrings(forward []string) (reversed []string) {
+	for i := len(forward) - 1; i >= 0; i-- {
+		reversed = append(reversed, forward[i])
+	}
+
+	return
+}
+
 // GetForwardCIDRStringForReverseZone generates a forward IP address from a reverse zone.
 func GetForwardCIDRStringForReverseZone(reverseZone *powerdns.Zone) (forwardCIDRString string, err error) {
 	if reverseZone == nil || reverseZone.Name == nil {
@@ -133,15 +222,41 @@ func GetNetworkForCIDRString(networks []sls_common.Network, cidr string) (*sls_c
 	return nil, nil
 }
 
+// GetRRsetKey returns the name and type that identify the RRset.
+func GetRRsetKey(rrSet powerdns.RRset) RRsetKey {
+	return RRsetKey{
+		Name: *rrSet.Name,
+		Type: *rrSet.Type,
+	}
+}
+
 func RRsetsEqual(a powerdns.RRset, b powerdns.RRset) bool {
 	if *a.Name != *b.Name ||
-		!reflect.DeepEqual(a.Records, b.Records) ||
 		*a.TTL != *b.TTL ||
 		*a.Type != *b.Type {
 		return false
 	}
 
-	return true
+	// The serial is PowerDNS' business, not ours.
+	if *a.Type == powerdns.RRTypeSOA {
+		return soaRecordsEqual(a.Records, b.Records)
+	}
+
+	// PowerDNS doesn't keep the records of an RRset in the order they were given.
+	return reflect.DeepEqual(SortRecords(a.Records), SortRecords(b.Records))
+}
+
+// SortRecords returns a copy of the records ordered by content.
+func SortRecords(records []powerdns.Record) []powerdns.Record {
+	sortedRecords := append([]powerdns.Record{}, records...)
+	sort.SliceStable(sortedRecords, func(i, j int) bool {
+		if sortedRecords[i].Content == nil || sortedRecords[j].Content == nil {
+			return sortedRecords[j].Content != nil
+		}
+		return *sortedRecords[i].Content < *sortedRecords[j].Content
+	})
+
+	return sortedRecords
 }
 
 func RRsetsContains(a []powerdns.RRset, b powerdns.RRset) bool {
@@ -179,6 +294,11 @@ func GetZoneForRRSet(rrSet powerdns.RRset, zones PowerDNSZones) *string {
 	sort.Sort(zones)
 
 	for _, zone := range zones {
+		// An NS RRset at the apex of a zone is the delegation to that zone and belongs in the parent.
+		if *rrSet.Type == powerdns.RRTypeNS && *rrSet.Name == *zone.Name {
+			continue
+		}
+
 		if strings.HasSuffix(*rrSet.Name, *zone.Name) {
 			return zone.Name
 		}
@@ -187,11 +307,13 @@ func GetZoneForRRSet(rrSet powerdns.RRset, zones PowerDNSZones) *string {
 	return nil
 }
 
-func GetNameserverRRset(nameserver Nameserver) powerdns.RRset {
+func GetNameserverRRset(nameserver Nameserver, ttls TTLs) powerdns.RRset {
+	rrType := GetAddressRRType(net.ParseIP(nameserver.IP))
+
 	return powerdns.RRset{
 		Name:       powerdns.String(MakeDomainCanonical(nameserver.FQDN)),
-		Type:       powerdns.RRTypePtr(powerdns.RRTypeA),
-		TTL:        powerdns.Uint32(3600),
+		Type:       powerdns.RRTypePtr(rrType),
+		TTL:        powerdns.Uint32(ttls.Get(rrType, "")),
 		ChangeType: powerdns.ChangeTypePtr(powerdns.ChangeTypeReplace),
 		Records: []powerdns.Record{
 			{
@@ -202,14 +324,15 @@ func GetNameserverRRset(nameserver Nameserver) powerdns.RRset {
 	}
 }
 
-func GetDNAMERRSet(masterZoneName string, baseDomain string, masterZoneNames []string) (rrSet powerdns.RRset, err error) {
+func GetDNAMERRSet(masterZoneName string, baseDomain string, masterZoneNames []string,
+	ttl uint32) (rrSet powerdns.RRset, err error) {
 	for _, zone := range masterZoneNames {
 		if strings.HasPrefix(zone, MakeDomainCanonical(masterZoneName)) && strings.HasSuffix(zone, baseDomain) {
 
 			rrSet = powerdns.RRset{
 				Name:       powerdns.String(MakeDomainCanonical(masterZoneName)),
 				Type:       powerdns.RRTypePtr(powerdns.RRTypeDNAME),
-				TTL:        powerdns.Uint32(3600),
+				TTL:        powerdns.Uint32(ttl),
 				ChangeType: powerdns.ChangeTypePtr(powerdns.ChangeTypeReplace),
 				Records: []powerdns.Record{
 					{
@@ -225,6 +348,8 @@ func GetDNAMERRSet(masterZoneName string, baseDomain string, masterZoneNames []s
 	return
 }
 
+// GetStartOfAuthorityRRSet returns the SOA for a zone. The serial is the first date based serial for today, after that
+// it is up to PowerDNS to increment it through SOA-EDIT-API every time the zone is changed.
 func GetStartOfAuthorityRRSet(zoneName string,
 	mname string,
 	rname string,
@@ -234,10 +359,10 @@ func GetStartOfAuthorityRRSet(zoneName string,
 	ttl string) powerdns.RRset {
 
 	// Generate a SOA record that has the correct nameserver name
-	soaString := fmt.Sprintf("%s %s %s %s %s %s %s",
+	soaString := fmt.Sprintf("%s %s %d %s %s %s %s",
 		mname,
 		rname,
-		"0", // Serial
+		GetDateSOASerial(time.Now()),
 		refresh,
 		retry,
 		expire,
@@ -247,7 +372,7 @@ func GetStartOfAuthorityRRSet(zoneName string,
 	return powerdns.RRset{
 		Name:       powerdns.String(MakeDomainCanonical(zoneName)),
 		Type:       powerdns.RRTypePtr(powerdns.RRTypeSOA),
-		TTL:        powerdns.Uint32(3600),
+		TTL:        powerdns.Uint32(DefaultTTL),
 		ChangeType: powerdns.ChangeTypePtr(powerdns.ChangeTypeReplace),
 		Records: []powerdns.Record{
 			{
@@ -0,0 +1,165 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package common
+
+import (
+	"net"
+	"testing"
+
+	"github.com/joeig/go-powerdns/v2"
+)
+
+func mustParseCIDR(t *testing.T, cidrString string) *net.IPNet {
+	_, cidr, err := net.ParseCIDR(cidrString)
+	if err != nil {
+		t.Fatalf("Failed to parse %s: %v", cidrString, err)
+	}
+
+	return cidr
+}
+
+func TestGetReverseZoneName(t *testing.T) {
+	tests := []struct {
+		cidr string
+		want string
+	}{
+		{cidr: "10.252.0.0/17", want: "252.10.in-addr.arpa"},
+		{cidr: "10.1.1.0/24", want: "1.1.10.in-addr.arpa"},
+		{cidr: "10.0.0.0/8", want: "10.in-addr.arpa"},
+		{cidr: "fd00:1234:5678::/48", want: "8.7.6.5.4.3.2.1.0.0.d.f.ip6.arpa"},
+		{cidr: "fd00:1234:5678::/50", want: "8.7.6.5.4.3.2.1.0.0.d.f.ip6.arpa"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.cidr, func(t *testing.T) {
+			if got := GetReverseZoneName(mustParseCIDR(t, test.cidr)); got != test.want {
+				t.Errorf("GetReverseZoneName(%s) = %s, want %s", test.cidr, got, test.want)
+			}
+		})
+	}
+}
+
+func TestClasslessReverseNames(t *testing.T) {
+	tests := []struct {
+		cidr          string
+		ip            string
+		wantClassless bool
+		wantZone      string
+		wantName      string
+	}{
+		{cidr: "10.101.5.128/26", ip: "10.101.5.130", wantClassless: true,
+			wantZone: "128-26.5.101.10.in-addr.arpa", wantName: "130.128-26.5.101.10.in-addr.arpa"},
+		{cidr: "10.101.5.0/25", ip: "10.101.5.1", wantClassless: true,
+			wantZone: "0-25.5.101.10.in-addr.arpa", wantName: "1.0-25.5.101.10.in-addr.arpa"},
+		{cidr: "10.101.5.0/24", wantClassless: false},
+		{cidr: "fd00::/64", wantClassless: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.cidr, func(t *testing.T) {
+			cidr := mustParseCIDR(t, test.cidr)
+			if got := IsClasslessCIDR(cidr); got != test.wantClassless {
+				t.Fatalf("IsClasslessCIDR(%s) = %t, want %t", test.cidr, got, test.wantClassless)
+			}
+			if !test.wantClassless {
+				return
+			}
+
+			if got := GetClasslessReverseZoneName(cidr); got != test.wantZone {
+				t.Errorf("GetClasslessReverseZoneName(%s) = %s, want %s", test.cidr, got, test.wantZone)
+			}
+			if got := GetClasslessReverseName(net.ParseIP(test.ip), cidr); got != test.wantName {
+				t.Errorf("GetClasslessReverseName(%s, %s) = %s, want %s", test.ip, test.cidr, got, test.wantName)
+			}
+		})
+	}
+}
+
+func TestReverseNameForIP(t *testing.T) {
+	tests := []struct {
+		ip       string
+		want     string
+		wantIPv6 bool
+	}{
+		{ip: "10.252.0.20", want: "20.0.252.10.in-addr.arpa"},
+		{ip: "fd00::1", want: "1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.d.f.ip6.arpa",
+			wantIPv6: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.ip, func(t *testing.T) {
+			got := GetReverseNameForIP(net.ParseIP(test.ip))
+			if got != test.want {
+				t.Fatalf("GetReverseNameForIP(%s) = %s, want %s", test.ip, got, test.want)
+			}
+			if IsIPv6ReverseName(got) != test.wantIPv6 {
+				t.Errorf("IsIPv6ReverseName(%s) = %t, want %t", got, !test.wantIPv6, test.wantIPv6)
+			}
+			if forwardIP := GetForwardIP(MakeDomainCanonical(got)); forwardIP != test.ip {
+				t.Errorf("GetForwardIP(%s) = %s, want %s", got, forwardIP, test.ip)
+			}
+		})
+	}
+}
+
+func TestRRsetsEqual(t *testing.T) {
+	makeRRset := func(ttl uint32, contents ...string) powerdns.RRset {
+		rrSet := powerdns.RRset{
+			Name: powerdns.String("x3000c0s2b0n0.nmn.example.com."),
+			Type: powerdns.RRTypePtr(powerdns.RRTypeA),
+			TTL:  powerdns.Uint32(ttl),
+		}
+		for _, content := range contents {
+			rrSet.Records = append(rrSet.Records, powerdns.Record{
+				Content:  powerdns.String(content),
+				Disabled: powerdns.Bool(false),
+			})
+		}
+		return rrSet
+	}
+
+	tests := []struct {
+		name string
+		a    powerdns.RRset
+		b    powerdns.RRset
+		want bool
+	}{
+		{name: "same", a: makeRRset(3600, "10.252.0.20"), b: makeRRset(3600, "10.252.0.20"), want: true},
+		{name: "record order doesn't matter", a: makeRRset(3600, "10.252.0.20", "10.252.0.21"),
+			b: makeRRset(3600, "10.252.0.21", "10.252.0.20"), want: true},
+		{name: "different records", a: makeRRset(3600, "10.252.0.20"), b: makeRRset(3600, "10.252.0.21")},
+		{name: "extra record", a: makeRRset(3600, "10.252.0.20"), b: makeRRset(3600, "10.252.0.20", "10.252.0.21")},
+		{name: "different TTL", a: makeRRset(3600, "10.252.0.20"), b: makeRRset(300, "10.252.0.20")},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := RRsetsEqual(test.a, test.b); got != test.want {
+				t.Errorf("RRsetsEqual() = %t, want %t", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Metrics are the Prometheus metrics shared by both managers. Each manager uses its own namespace so the metrics can
+// be told apart when scraped from the same pod.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	TrueUpDuration       prometheus.Histogram
+	LastSuccessTimestamp prometheus.Gauge
+	FetchDuration        *prometheus.HistogramVec
+	FetchErrors          *prometheus.CounterVec
+	RRsetsPatched        *prometheus.CounterVec
+	PatchFailures        *prometheus.CounterVec
+	NotifyFailures       *prometheus.CounterVec
+	RecordConflicts      *prometheus.GaugeVec
+}
+
+func NewMetrics(namespace string) *Metrics {
+	metrics := &Metrics{
+		registry: prometheus.NewRegistry(),
+
+		TrueUpDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "true_up_duration_seconds",
+			Help:      "Time taken by each run of the true up loop.",
+			Buckets:   []float64{0.5, 1, 2.5, 5, 10, 20, 30, 60, 120, 300},
+		}),
+		LastSuccessTimestamp: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "last_success_timestamp_seconds",
+			Help:      "Unix time of the last true up run that completed without errors.",
+		}),
+		FetchDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "fetch_duration_seconds",
+			Help:      "Time taken to fetch data from an upstream service.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"service", "resource"}),
+		FetchErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "fetch_errors_total",
+			Help:      "Number of failed fetches from an upstream service.",
+		}, []string{"service", "resource"}),
+		RRsetsPatched: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "rrsets_patched_total",
+			Help:      "Number of RRsets successfully patched in PowerDNS.",
+		}, []string{"zone", "change"}),
+		PatchFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "patch_failures_total",
+			Help:      "Number of failed attempts to patch the RRsets of a zone.",
+		}, []string{"zone"}),
+		NotifyFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "notify_failures_total",
+			Help:      "Number of failed attempts to send a DNS NOTIFY for a zone.",
+		}, []string{"zone"}),
+		RecordConflicts: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "record_conflicts",
+			Help:      "Number of RRsets dropped by the last full true up run because they conflicted with another.",
+		}, []string{"reason"}),
+	}
+
+	metrics.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		metrics.TrueUpDuration,
+		metrics.LastSuccessTimestamp,
+		metrics.FetchDuration,
+		metrics.FetchErrors,
+		metrics.RRsetsPatched,
+		metrics.PatchFailures,
+		metrics.NotifyFailures,
+		metrics.RecordConflicts,
+	)
+
+	return metrics
+}
+
+// Handler returns the HTTP handler for the /metrics endpoint.
+func (metrics *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(metrics.registry, promhttp.HandlerOpts{})
+}
+
+// ObserveFetch records how long a fetch from an upstream service took and whether it failed.
+func (metrics *Metrics) ObserveFetch(service string, resource string, start time.Time, err error) {
+	metrics.FetchDuration.WithLabelValues(service, resource).Observe(time.Since(start).Seconds())
+	if err != nil {
+		metrics.FetchErrors.WithLabelValues(service, resource).Inc()
+	}
+}
+
+// ObserveTrueUp records how long a true up run took and, if it was successful, when it finished.
+func (metrics *Metrics) ObserveTrueUp(start time.Time, success bool) {
+	metrics.TrueUpDuration.Observe(time.Since(start).Seconds())
+	if success {
+		metrics.LastSuccessTimestamp.SetToCurrentTime()
+	}
+}
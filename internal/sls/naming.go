@@ -0,0 +1,109 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package sls
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	sls_common "github.com/Cray-HPE/hms-sls/pkg/sls-common"
+	"github.com/mitchellh/mapstructure"
+)
+
+// The rules for turning SLS reservations into DNS names, shared by the manager and the linter so they can't drift
+// apart.
+
+// hsnReservationRegex pulls the node xname and NIC out of an HSN reservation name.
+var hsnReservationRegex = regexp.MustCompile(`(?m)(?P<Xname>^x.*)h(?P<Nic>\d+)`)
+
+// GetHardwareMap indexes the hardware by xname.
+func GetHardwareMap(hardware []sls_common.GenericHardware) map[string]sls_common.GenericHardware {
+	hardwareMap := make(map[string]sls_common.GenericHardware)
+	for _, device := range hardware {
+		hardwareMap[device.Xname] = device
+	}
+
+	return hardwareMap
+}
+
+// GetReservationNode returns the node a reservation is for. For some reason the xname for some reservations is in the
+// comment, if the comment is an xname in the hardware the node gets its own name and the reservation name is an alias
+// of it.
+func GetReservationNode(reservation IPReservation,
+	hardwareMap map[string]sls_common.GenericHardware) (node sls_common.GenericHardware, found bool) {
+	node, found = hardwareMap[reservation.Comment]
+	return
+}
+
+// GetReservationAliases returns the aliases of a reservation that become names and the ones that are skipped because
+// they contain a dot, such as something.local. An alias that is just the node xname is dropped as well as the node
+// already has that name.
+func GetReservationAliases(reservation IPReservation, nodeXname string) (aliases []string, dottedAliases []string) {
+	for _, alias := range reservation.Aliases {
+		if strings.Contains(alias, ".") {
+			dottedAliases = append(dottedAliases, alias)
+			continue
+		}
+		if alias == nodeXname {
+			continue
+		}
+		aliases = append(aliases, alias)
+	}
+
+	return
+}
+
+// ParseHSNReservationName splits an HSN reservation name, <node xname>h<NIC>, into the node xname and the NIC.
+func ParseHSNReservationName(name string) (xname string, nic int, err error) {
+	matches := hsnReservationRegex.FindStringSubmatch(name)
+	if matches == nil {
+		err = fmt.Errorf("name %s is not in the <node xname>h<NIC> format", name)
+		return
+	}
+
+	xname = matches[hsnReservationRegex.SubexpIndex("Xname")]
+	nic, err = strconv.Atoi(matches[hsnReservationRegex.SubexpIndex("Nic")])
+	if err != nil {
+		err = fmt.Errorf("name %s has an invalid NIC: %w", name, err)
+	}
+
+	return
+}
+
+// GetNodeNID returns the NID SLS has for the node, 0 if it doesn't have one. Application nodes get theirs from HSM.
+func GetNodeNID(node sls_common.GenericHardware) (nid int, err error) {
+	var extraProperties sls_common.ComptypeNode
+	err = mapstructure.Decode(node.ExtraPropertiesRaw, &extraProperties)
+	if err != nil {
+		err = fmt.Errorf("unable to decode node %s extra properties: %w", node.Xname, err)
+		return
+	}
+
+	nid = extraProperties.NID
+	return
+}
@@ -0,0 +1,88 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package sls
+
+import (
+	"reflect"
+	"testing"
+
+	sls_common "github.com/Cray-HPE/hms-sls/pkg/sls-common"
+)
+
+func TestGetReservationAliases(t *testing.T) {
+	reservation := IPReservation{
+		Name:    "ncn-w001",
+		Aliases: []string{"ncn-w001-nmn", "time-nmn.local", "x3000c0s4b0n0", "worker1"},
+	}
+
+	aliases, dottedAliases := GetReservationAliases(reservation, "x3000c0s4b0n0")
+	if want := []string{"ncn-w001-nmn", "worker1"}; !reflect.DeepEqual(aliases, want) {
+		t.Errorf("GetReservationAliases() aliases = %v, want %v", aliases, want)
+	}
+	if want := []string{"time-nmn.local"}; !reflect.DeepEqual(dottedAliases, want) {
+		t.Errorf("GetReservationAliases() dotted aliases = %v, want %v", dottedAliases, want)
+	}
+}
+
+func TestParseHSNReservationName(t *testing.T) {
+	tests := []struct {
+		name      string
+		wantXname string
+		wantNic   int
+		wantErr   bool
+	}{
+		{name: "x1000c0s0b0n0h0", wantXname: "x1000c0s0b0n0", wantNic: 0},
+		{name: "x1000c0s0b0n1h3", wantXname: "x1000c0s0b0n1", wantNic: 3},
+		{name: "uan01", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			xname, nic, err := ParseHSNReservationName(test.name)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("ParseHSNReservationName() error = %v, wantErr %v", err, test.wantErr)
+			}
+			if xname != test.wantXname || nic != test.wantNic {
+				t.Errorf("ParseHSNReservationName() = %s, %d, want %s, %d", xname, nic, test.wantXname,
+					test.wantNic)
+			}
+		})
+	}
+}
+
+func TestGetNodeNID(t *testing.T) {
+	hardwareMap := GetHardwareMap([]sls_common.GenericHardware{
+		{Xname: "x1000c0s0b0n0", ExtraPropertiesRaw: map[string]interface{}{"NID": 1000}},
+		{Xname: "x3000c0s27b0n0", ExtraPropertiesRaw: map[string]interface{}{"Role": "Application"}},
+	})
+
+	if nid, err := GetNodeNID(hardwareMap["x1000c0s0b0n0"]); err != nil || nid != 1000 {
+		t.Errorf("GetNodeNID() = %d, %v, want 1000", nid, err)
+	}
+	if nid, err := GetNodeNID(hardwareMap["x3000c0s27b0n0"]); err != nil || nid != 0 {
+		t.Errorf("GetNodeNID() = %d, %v, want 0", nid, err)
+	}
+}